@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+	"github.com/FourPalms/golang-slack-monitor/notification"
+	"github.com/FourPalms/golang-slack-monitor/slack"
+	"github.com/FourPalms/golang-slack-monitor/storage"
+)
+
+// onceDeliveryTimeout bounds how long "once" waits for its notifications to be
+// delivered; anything undelivered stays in the outbox for the next run
+const onceDeliveryTimeout = 15 * time.Second
+
+// commands lists the subcommands in the order usage shows them
+var commands = []struct {
+	name, summary string
+}{
+	{"run", "Monitor Slack until interrupted (the default)"},
+	{"once", "Run one check cycle, deliver its notifications and exit (for cron)"},
+	{"status", "Show saved state: tracked conversations, last messages, pending notifications"},
+	{"list", "List monitored conversations with their names and polling tiers"},
+	{"auth", "Check the Slack tokens and show the authenticated user and workspace"},
+	{"test-notify", "Send a test notification to the configured targets (-target, -text)"},
+	{"rules", "Show which rule a message would match (see rules -h)"},
+}
+
+// isCommand reports whether name is a known subcommand
+func isCommand(name string) bool {
+	for _, c := range commands {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// printUsage lists the subcommands
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: slack-monitor [--config FILE] [--state-dir DIR] [command] [flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	fmt.Fprintf(out, "  --config FILE    Config file (default $%s, ~/%s/config.json if that\n", envConfigPath, legacyDir)
+	fmt.Fprintln(out, "                   directory exists, else $XDG_CONFIG_HOME/slack-monitor/config.json)")
+	fmt.Fprintf(out, "  --state-dir DIR  State and outbox directory (default $%s, ~/%s if it\n", envStateDir, legacyDir)
+	fmt.Fprintln(out, "                   exists, else $XDG_STATE_HOME/slack-monitor)")
+	fmt.Fprintf(out, "\nAny config field can be overridden with %s<PATH>, e.g. %sSLACK_XOXC_TOKEN.\n", envPrefix, envPrefix)
+}
+
+// runOnce runs a single check cycle, waits for the resulting notifications to
+// be delivered and returns the process exit code
+func runOnce(config *monitor.Config, loc locations) int {
+	dispatcher, outbox := newDelivery(config, loc.stateDir)
+	mon := monitor.NewMonitor(newSlackClient(config), outbox, storage.NewFileStore(loc.stateDir), config)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Delivery runs on its own context so it can finish after the check
+	deliveryCtx, stopDelivery := context.WithCancel(context.Background())
+	go outbox.Run(deliveryCtx)
+	dispatcherDone := make(chan struct{})
+	go func() {
+		dispatcher.Run(deliveryCtx)
+		close(dispatcherDone)
+	}()
+
+	err := mon.RunOnce(ctx)
+	if err != nil {
+		log.Printf("Check failed: %v", err)
+	}
+
+	deadline := time.After(onceDeliveryTimeout)
+wait:
+	for outbox.Pending() > 0 {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-deadline:
+			break wait
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	stopDelivery()
+	<-dispatcherDone
+	if pending := outbox.Pending(); pending > 0 {
+		log.Printf("%d notification(s) left in the outbox for the next run", pending)
+	}
+
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// runStatus prints the saved state without contacting Slack: each tracked
+// conversation's newest message and polling tier, watched threads, and
+// notifications waiting for the digest, quiet hours or delivery. It returns
+// the process exit code.
+func runStatus(config *monitor.Config, store monitor.StateStore, undelivered int, now time.Time, out io.Writer) int {
+	state, err := store.Load()
+	if err != nil {
+		fmt.Fprintf(out, "Failed to load state: %v\n", err)
+		return 1
+	}
+
+	ids := make([]string, 0, len(state.LastChecked))
+	for id := range state.LastChecked {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := state.LastActivity(ids[i]), state.LastActivity(ids[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return ids[i] < ids[j]
+	})
+
+	fmt.Fprintf(out, "Tracked conversations: %d\n", len(ids))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, id := range ids {
+		last := state.LastActivity(id)
+		line := fmt.Sprintf("  %s\t%s\t%s", id, config.Tier(last, now), formatLastMessage(last, now))
+		if threads := len(state.Threads[id]); threads > 0 {
+			line += fmt.Sprintf("\t%d watched thread(s)", threads)
+		}
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+
+	digest := 0
+	for _, buffered := range state.Digest {
+		digest += len(buffered)
+	}
+	fmt.Fprintf(out, "Digest: %d notification(s) buffered", digest)
+	if !state.LastDigest.IsZero() {
+		fmt.Fprintf(out, ", last due %s", state.LastDigest.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Held for quiet hours: %d notification(s)\n", len(state.Held))
+	fmt.Fprintf(out, "Outbox: %d undelivered notification(s)\n", undelivered)
+	return 0
+}
+
+// runList prints the conversations the monitor checks, with resolved names,
+// polling tiers from the saved state and newest messages. It returns the
+// process exit code.
+func runList(ctx context.Context, mon *monitor.Monitor, store monitor.StateStore, out io.Writer) int {
+	state, err := store.Load()
+	if err != nil {
+		fmt.Fprintf(out, "Failed to load state: %v\n", err)
+		return 1
+	}
+	conversations, err := mon.ListConversations(ctx, state)
+	if err != nil {
+		fmt.Fprintf(out, "Failed to list conversations: %v\n", err)
+		return 1
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIER\tID\tCONVERSATION\tLAST MESSAGE")
+	for _, conv := range conversations {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", conv.Tier, conv.ID, conv.Label, formatLastMessage(conv.LastActivity, now))
+	}
+	w.Flush()
+	fmt.Fprintf(out, "%d conversation(s)\n", len(conversations))
+	return 0
+}
+
+// runAuth checks the Slack tokens and prints the authenticated user and
+// workspace. It returns the process exit code.
+func runAuth(ctx context.Context, client monitor.SlackClient, out io.Writer) int {
+	userID, err := client.TestAuth(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Authentication failed: %v\n", err)
+		if slack.IsAuthError(err) {
+			fmt.Fprintln(out, "The session has expired; extract new xoxc/xoxd tokens and update the config file.")
+		}
+		return 1
+	}
+
+	name := userID
+	if user, err := client.GetUserInfo(ctx, userID); err == nil && user.Name != "" {
+		name = fmt.Sprintf("@%s (%s)", user.Name, userID)
+		if user.RealName != "" {
+			name = user.RealName + " " + name
+		}
+	}
+	fmt.Fprintf(out, "Authenticated as %s\n", name)
+	workspace := client.GetTeamID()
+	if url := client.GetWorkspaceURL(); url != "" {
+		workspace += " " + url
+	}
+	fmt.Fprintf(out, "Workspace: %s\n", workspace)
+	return 0
+}
+
+// runTestNotify sends a test notification to each target in turn and reports
+// the outcome for each. It returns the process exit code: 1 if any target failed.
+func runTestNotify(targets *notification.Multi, args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("test-notify", flag.ContinueOnError)
+	fs.SetOutput(out)
+	target := fs.String("target", "", "only send to this target, by name (default all)")
+	text := fs.String("text", "", "notification text")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	names := targets.Names()
+	if *target != "" {
+		names = []string{*target}
+	}
+	now := time.Now()
+	n := monitor.Notification{
+		Subject:   "Slack Monitor test",
+		Sender:    "Slack Monitor",
+		Text:      *text,
+		Timestamp: fmt.Sprintf("%d.000000", now.Unix()),
+		Priority:  monitor.PriorityDefault,
+	}
+	if n.Text == "" {
+		n.Text = "Test notification from slack-monitor at " + now.Format("15:04:05")
+	}
+
+	failed := 0
+	for _, name := range names {
+		n.Targets = []string{name}
+		if err := targets.SendNotification(n); err != nil {
+			// The error names the target already
+			fmt.Fprintf(out, "%s: failed: %s\n", name, strings.TrimPrefix(err.Error(), name+": "))
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s: sent\n", name)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// formatLastMessage describes when the newest message was seen, e.g.
+// "2024-03-01 14:03 (2h ago)"
+func formatLastMessage(t, now time.Time) string {
+	if t.IsZero() {
+		return "no messages seen"
+	}
+	return t.Local().Format("2006-01-02 15:04") + " (" + formatAge(now.Sub(t)) + " ago)"
+}
+
+// formatAge rounds a duration to its largest unit: "45s", "12m", "5h" or "3d"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// envPrefix starts the environment variables that override config fields
+const envPrefix = "SLACK_MONITOR_"
+
+// applyEnv overrides config fields from SLACK_MONITOR_* environment variables,
+// looked up with lookup (os.LookupEnv outside tests). Each field's variable is
+// named after its JSON path in upper case, e.g. SLACK_MONITOR_SLACK_XOXC_TOKEN
+// for slack.xoxc_token. Lists of strings may be comma-separated; other lists,
+// maps and objects (such as rules) are given as JSON.
+func applyEnv(config *monitor.Config, lookup func(string) (string, bool)) error {
+	return applyEnvFields(reflect.ValueOf(config).Elem(), envPrefix, lookup)
+}
+
+// applyEnvFields overrides the fields of struct v from variables named prefix
+// plus the field's JSON name, descending into nested structs
+func applyEnvFields(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		key := prefix + strings.ToUpper(name)
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !decodesItself(fv) {
+			if err := applyEnvFields(fv, key+"_", lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(fv, value); err != nil {
+			return fmt.Errorf("environment variable %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// decodesItself reports whether v's type has its own JSON or text decoding
+func decodesItself(v reflect.Value) bool {
+	ptr := v.Addr().Interface()
+	_, isJSON := ptr.(json.Unmarshaler)
+	_, isText := ptr.(encoding.TextUnmarshaler)
+	return isJSON || isText
+}
+
+// setFromEnv parses an environment variable's value into v
+func setFromEnv(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromEnv(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		v.SetInt(int64(n))
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			v.Set(reflect.ValueOf(items).Convert(v.Type()))
+			return nil
+		}
+	}
+	if err := json.Unmarshal([]byte(value), v.Addr().Interface()); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
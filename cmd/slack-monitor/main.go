@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
+	"time"
+	_ "time/tzdata" // Schedule time zones work without system zoneinfo
 
 	"github.com/FourPalms/golang-slack-monitor"
 	"github.com/FourPalms/golang-slack-monitor/notification"
@@ -19,30 +25,109 @@ import (
 const (
 	defaultPollIntervalSecs = 60
 	defaultDMsOnly          = true
+	defaultTieredPolling    = false
+	defaultRealtime         = false
 )
 
+// validConversationTypes lists the accepted monitor.conversation_types values
+var validConversationTypes = map[string]bool{
+	monitor.ConversationTypeIM:             true,
+	monitor.ConversationTypeMPIM:           true,
+	monitor.ConversationTypePrivateChannel: true,
+	monitor.ConversationTypePublicChannel:  true,
+}
+
 func main() {
 	log.SetFlags(log.Ldate | log.Ltime)
-	log.Println("Slack Monitor starting...")
 
-	// Load configuration
-	config, err := loadConfig()
+	// Global flags come before the subcommand; without one the monitor runs
+	fs := flag.NewFlagSet("slack-monitor", flag.ContinueOnError)
+	fs.Usage = func() { printUsage(os.Stderr) }
+	configFlag := fs.String("config", "", "config file")
+	stateDirFlag := fs.String("state-dir", "", "directory for the state and outbox files")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+	command, args := "run", fs.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	if command == "help" {
+		printUsage(os.Stdout)
+		return
+	}
+	if !isCommand(command) {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	if command != "rules" && command != "test-notify" && len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "%s takes no arguments\n\n", command)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+
+	// Load configuration: flags, then environment, then the config file, then defaults
+	loc, err := resolveLocations(*configFlag, *stateDirFlag)
+	if err != nil {
+		log.Fatalf("Failed to locate config: %v", err)
+	}
+	config, err := loadConfig(loc.configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	switch command {
+	case "run":
+		runMonitor(config, loc)
+	case "once":
+		os.Exit(runOnce(config, loc))
+	case "status":
+		// The outbox is only read, so it needs no notifier
+		undelivered := openOutbox(loc.stateDir, nil).Pending()
+		os.Exit(runStatus(config, storage.NewFileStore(loc.stateDir), undelivered, time.Now(), os.Stdout))
+	case "test-notify":
+		os.Exit(runTestNotify(newTargets(config), args, os.Stdout))
+	case "list":
+		mon := monitor.NewMonitor(newSlackClient(config), nil, nil, config)
+		os.Exit(runList(context.Background(), mon, storage.NewFileStore(loc.stateDir), os.Stdout))
+	case "auth":
+		os.Exit(runAuth(context.Background(), newSlackClient(config), os.Stdout))
+	case "rules":
+		// Shows which rule a message would match, without contacting Slack
+		os.Exit(runRules(config, args, os.Stdout))
+	}
+}
+
+// runMonitor monitors Slack until interrupted
+func runMonitor(config *monitor.Config, loc locations) {
+	log.Println("Slack Monitor starting...")
+	log.Printf("Using config %s and state directory %s", loc.configPath, loc.stateDir)
+
 	// Create implementations
-	slackClient := slack.NewClient(config.Slack.XoxcToken, config.Slack.XoxdToken)
-	notifier := notification.NewService(config.Notifications.NtfyTopic)
-	stateStore := storage.NewFileStore()
+	slackClient := newSlackClient(config)
+	dispatcher, outbox := newDelivery(config, loc.stateDir)
+	stateStore := storage.NewFileStore(loc.stateDir)
 
 	// Create monitor with injected dependencies
-	mon := monitor.NewMonitor(slackClient, notifier, stateStore, config)
+	mon := monitor.NewMonitor(slackClient, outbox, stateStore, config)
+
+	// When the Slack session expires, pick up new tokens from the config file
+	mon.SetCredentialSource(configCredentials{path: loc.configPath})
 
 	// Set up context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Reload the configuration on SIGHUP, or when the config file changes
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	reloader := &configReloader{path: loc.configPath, current: config, dispatcher: dispatcher, monitor: mon}
+	go reloader.watch(ctx, hangup)
+
 	// Handle SIGINT and SIGTERM for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -53,38 +138,101 @@ func main() {
 		cancel()
 	}()
 
+	// Deliver notifications in the background: the outbox persists and retries,
+	// the dispatcher rate limits and coalesces. Undelivered notifications stay in
+	// the outbox across restarts.
+	go outbox.Run(ctx)
+	dispatcherDone := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(dispatcherDone)
+	}()
+
 	// Run the monitor
 	log.Println("Starting monitoring...")
 	if err := mon.Run(ctx); err != nil {
 		log.Fatalf("Monitor error: %v", err)
 	}
+	<-dispatcherDone
 
 	log.Println("Monitoring stopped")
 }
 
-// loadConfig loads and validates the configuration file
-func loadConfig() (*monitor.Config, error) {
-	// Get home directory
-	home, err := os.UserHomeDir()
+// newSlackClient creates the Slack client, exiting if its settings are invalid
+func newSlackClient(config *monitor.Config) *slack.Client {
+	slackOpts, err := slackClientOptions(config)
+	if err != nil {
+		log.Fatalf("Invalid Slack client settings: %v", err)
+	}
+	return slack.NewClient(config.Slack.XoxcToken, config.Slack.XoxdToken, slackOpts...)
+}
+
+// newTargets creates the configured notification targets, exiting if their
+// settings are invalid
+func newTargets(config *monitor.Config) *notification.Multi {
+	targets, err := notification.NewFromConfig(config)
+	if err != nil {
+		log.Fatalf("Invalid notification settings: %v", err)
+	}
+	return targets
+}
+
+// newDelivery creates the notification pipeline: the outbox in stateDir that
+// persists and retries, in front of the dispatcher that rate limits and coalesces
+func newDelivery(config *monitor.Config, stateDir string) (*notification.Dispatcher, *storage.Outbox) {
+	targets := newTargets(config)
+	log.Printf("Sending notifications to %d target(s)", targets.Len())
+	dispatcher := notification.NewDispatcher(targets, config.Notifications.MaxPerMinute)
+	return dispatcher, openOutbox(stateDir, dispatcher)
+}
+
+// openOutbox opens the notification outbox in stateDir, exiting if it can't be read
+func openOutbox(stateDir string, notifier monitor.Notifier) *storage.Outbox {
+	outbox, err := storage.NewOutbox(stateDir, notifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		log.Fatalf("Failed to open notification outbox: %v", err)
 	}
+	return outbox
+}
 
-	// Construct config path
-	configPath := filepath.Join(home, ".slack-monitor", "config.json")
+// configCredentials reads the Slack tokens from the config file each time they
+// are needed, so new tokens are picked up without a restart
+type configCredentials struct {
+	path string
+}
+
+// SlackCredentials returns the tokens currently configured
+func (c configCredentials) SlackCredentials() (string, string, error) {
+	config, err := loadConfig(c.path)
+	if err != nil {
+		return "", "", err
+	}
+	return config.Slack.XoxcToken, config.Slack.XoxdToken, nil
+}
 
+// loadConfig loads the configuration file at configPath, applies environment
+// overrides and validates the result
+func loadConfig(configPath string) (*monitor.Config, error) {
 	// Read config file
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file at %s: %w\nPlease create config file with your Slack tokens", configPath, err)
 	}
 
-	// Parse JSON
+	// Parse JSON over defaults so unset booleans keep their default value
 	var config monitor.Config
+	config.Monitor.DMsOnly = defaultDMsOnly
+	config.Monitor.TieredPolling = defaultTieredPolling
+	config.Monitor.Realtime = defaultRealtime
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// SLACK_MONITOR_* environment variables override the file, e.g. to keep tokens out of it
+	if err := applyEnv(&config, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
 	// Validate required fields
 	if config.Slack.XoxcToken == "" {
 		return nil, fmt.Errorf("slack.xoxc_token is required in config")
@@ -92,17 +240,122 @@ func loadConfig() (*monitor.Config, error) {
 	if config.Slack.XoxdToken == "" {
 		return nil, fmt.Errorf("slack.xoxd_token is required in config")
 	}
-	if config.Notifications.NtfyTopic == "" {
-		return nil, fmt.Errorf("notifications.ntfy_topic is required in config")
+	if config.Notifications.NtfyTopic == "" && len(config.Notifications.Targets) == 0 {
+		return nil, fmt.Errorf("notifications.ntfy_topic or notifications.targets is required in config")
+	}
+	for i, target := range config.Notifications.Targets {
+		if target.Type == "" {
+			return nil, fmt.Errorf("notifications.targets[%d]: type is required", i)
+		}
 	}
 
 	// Set defaults
 	if config.Slack.PollIntervalSecs == 0 {
 		config.Slack.PollIntervalSecs = defaultPollIntervalSecs
 	}
-	if !config.Monitor.DMsOnly {
-		config.Monitor.DMsOnly = defaultDMsOnly
+	tiers := config.Monitor.Tiers
+	if tiers.HotWithinHours < 0 || tiers.WarmWithinDays < 0 || tiers.WarmIntervalSecs < 0 || tiers.ColdIntervalSecs < 0 {
+		return nil, fmt.Errorf("monitor.tiers values must not be negative")
+	}
+	if config.Monitor.RealtimePollSecs < 0 {
+		return nil, fmt.Errorf("monitor.realtime_poll_seconds must not be negative")
+	}
+	if config.Monitor.CycleTimeoutSecs < 0 {
+		return nil, fmt.Errorf("monitor.cycle_timeout_seconds must not be negative")
+	}
+	if config.Monitor.Workers < 0 {
+		return nil, fmt.Errorf("monitor.workers must not be negative")
+	}
+	if config.Notifications.MaxPerMinute < 0 {
+		return nil, fmt.Errorf("notifications.max_per_minute must not be negative")
+	}
+	if !config.Monitor.DMsOnly && len(config.Monitor.ConversationTypes) == 0 {
+		config.Monitor.ConversationTypes = monitor.DefaultConversationTypes
+	}
+
+	// Validate conversation types and filter keys
+	for _, t := range config.Monitor.ConversationTypes {
+		if !validConversationTypes[t] {
+			return nil, fmt.Errorf("monitor.conversation_types: unknown type %q (valid: im, mpim, private_channel, public_channel)", t)
+		}
+	}
+	for t := range config.Monitor.Filters {
+		if !validConversationTypes[t] {
+			return nil, fmt.Errorf("monitor.filters: unknown conversation type %q", t)
+		}
+	}
+
+	// Validate notification rules, including the targets they route to
+	targetNames := make(map[string]bool)
+	if config.Notifications.NtfyTopic != "" {
+		targetNames["ntfy"] = true
+	}
+	for _, target := range config.Notifications.Targets {
+		targetNames[target.DisplayName()] = true
+	}
+	for i, rule := range config.Rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		for _, name := range rule.Targets {
+			if !targetNames[name] {
+				return nil, fmt.Errorf("rules[%d]: unknown notification target %q", i, name)
+			}
+		}
+	}
+
+	if err := config.Schedule.Validate(); err != nil {
+		return nil, fmt.Errorf("schedule: %w", err)
 	}
 
 	return &config, nil
 }
+
+// slackClientOptions translates the slack config section into client options
+func slackClientOptions(config *monitor.Config) ([]slack.Option, error) {
+	opts := []slack.Option{
+		slack.WithMaxPages(config.Slack.MaxPages),
+	}
+
+	if config.Slack.APIBaseURL != "" {
+		u, err := url.Parse(config.Slack.APIBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("slack.api_base_url must be an absolute http(s) URL, got %q", config.Slack.APIBaseURL)
+		}
+		opts = append(opts, slack.WithBaseURL(config.Slack.APIBaseURL))
+	}
+
+	if config.Slack.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.Slack.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("slack.proxy_url is not a valid URL: %q", config.Slack.ProxyURL)
+		}
+		opts = append(opts, slack.WithProxy(proxyURL))
+	}
+
+	if config.Slack.TLS.CAFile != "" || config.Slack.TLS.InsecureSkipVerify {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: config.Slack.TLS.InsecureSkipVerify,
+		}
+		if config.Slack.TLS.CAFile != "" {
+			pem, err := os.ReadFile(config.Slack.TLS.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read slack.tls.ca_file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("slack.tls.ca_file %s contains no PEM certificates", config.Slack.TLS.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if config.Slack.TLS.InsecureSkipVerify {
+			log.Println("Warning: TLS certificate verification is disabled for Slack requests")
+		}
+		opts = append(opts, slack.WithTLSConfig(tlsConfig))
+	}
+
+	return opts, nil
+}
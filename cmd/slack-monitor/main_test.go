@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+	"github.com/FourPalms/golang-slack-monitor/notification"
+	"github.com/FourPalms/golang-slack-monitor/slack"
+	"github.com/FourPalms/golang-slack-monitor/slacktest"
 )
 
 // TestLoadConfig tests config loading and validation
@@ -12,13 +22,8 @@ func TestLoadConfig(t *testing.T) {
 	// Create temp directory for test config
 	tmpDir := t.TempDir()
 
-	// Save original HOME and restore after test
-	origHome := os.Getenv("HOME")
-	defer os.Setenv("HOME", origHome)
-	os.Setenv("HOME", tmpDir)
-
 	// Test 1: Missing config file
-	_, err := loadConfig()
+	_, err := loadConfig(filepath.Join(tmpDir, ".slack-monitor", "config.json"))
 	if err == nil {
 		t.Error("Expected error for missing config file")
 	}
@@ -50,7 +55,7 @@ func TestLoadConfig(t *testing.T) {
 		t.Fatalf("Failed to write config: %v", err)
 	}
 
-	config, err := loadConfig()
+	config, err := loadConfig(configPath)
 	if err != nil {
 		t.Errorf("Expected no error for valid config, got: %v", err)
 	}
@@ -76,7 +81,7 @@ func TestLoadConfig(t *testing.T) {
 		t.Fatalf("Failed to write invalid config: %v", err)
 	}
 
-	_, err = loadConfig()
+	_, err = loadConfig(configPath)
 	if err == nil {
 		t.Error("Expected error for missing required field")
 	}
@@ -85,9 +90,6 @@ func TestLoadConfig(t *testing.T) {
 // TestConfigDefaults tests that default values are applied correctly
 func TestConfigDefaults(t *testing.T) {
 	tmpDir := t.TempDir()
-	origHome := os.Getenv("HOME")
-	defer os.Setenv("HOME", origHome)
-	os.Setenv("HOME", tmpDir)
 
 	// Config without optional fields
 	minimalConfig := map[string]interface{}{
@@ -111,7 +113,7 @@ func TestConfigDefaults(t *testing.T) {
 		t.Fatalf("Failed to write config: %v", err)
 	}
 
-	config, err := loadConfig()
+	config, err := loadConfig(configPath)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -124,3 +126,455 @@ func TestConfigDefaults(t *testing.T) {
 		t.Errorf("Expected default DMsOnly %v, got %v", defaultDMsOnly, config.Monitor.DMsOnly)
 	}
 }
+
+// TestSlackClientOptions tests validation of the Slack transport settings
+func TestSlackClientOptions(t *testing.T) {
+	config := &monitor.Config{}
+	if _, err := slackClientOptions(config); err != nil {
+		t.Errorf("Expected no error for default settings, got: %v", err)
+	}
+
+	config.Slack.APIBaseURL = "https://acme.slack.com/api/"
+	config.Slack.ProxyURL = "http://proxy.internal:3128"
+	if _, err := slackClientOptions(config); err != nil {
+		t.Errorf("Expected no error for valid base URL and proxy, got: %v", err)
+	}
+
+	config.Slack.APIBaseURL = "acme.slack.com/api"
+	if _, err := slackClientOptions(config); err == nil {
+		t.Error("Expected error for relative base URL")
+	}
+
+	config.Slack.APIBaseURL = ""
+	config.Slack.TLS.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := slackClientOptions(config); err == nil {
+		t.Error("Expected error for missing CA file")
+	}
+}
+
+// writeConfig writes a valid minimal config file to a new temp directory and
+// returns its path. Each entry in sections replaces that top-level section of
+// the minimal config, or adds it.
+func writeConfig(t *testing.T, sections map[string]interface{}) string {
+	t.Helper()
+	config := map[string]interface{}{
+		"slack": map[string]interface{}{
+			"xoxc_token": "test-xoxc",
+			"xoxd_token": "test-xoxd",
+		},
+		"notifications": map[string]interface{}{
+			"ntfy_topic": "test-topic",
+		},
+	}
+	for name, section := range sections {
+		config[name] = section
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data, _ := json.Marshal(config)
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return configPath
+}
+
+// TestConfigChannelMode tests that dms_only=false is honored and conversation types are validated
+func TestConfigChannelMode(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, map[string]interface{}{
+		"monitor": map[string]interface{}{"dms_only": false},
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if config.Monitor.DMsOnly {
+		t.Error("Expected dms_only=false to be preserved")
+	}
+	if len(config.Monitor.ConversationTypes) != len(monitor.DefaultConversationTypes) {
+		t.Errorf("Expected default conversation types, got %v", config.Monitor.ConversationTypes)
+	}
+
+	_, err = loadConfig(writeConfig(t, map[string]interface{}{
+		"monitor": map[string]interface{}{
+			"dms_only":           false,
+			"conversation_types": []string{"im", "channels"},
+		},
+	}))
+	if err == nil {
+		t.Error("Expected error for unknown conversation type")
+	}
+}
+
+// TestConfigNotificationTargets tests that targets can replace ntfy_topic
+func TestConfigNotificationTargets(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, map[string]interface{}{
+		"notifications": map[string]interface{}{
+			"targets": []map[string]interface{}{
+				{"type": "gotify", "name": "home", "url": "https://gotify.example.com", "token": "abc"},
+			},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(config.Notifications.Targets) != 1 || config.Notifications.Targets[0].DisplayName() != "home" {
+		t.Errorf("Expected one target named home, got %+v", config.Notifications.Targets)
+	}
+
+	if _, err := loadConfig(writeConfig(t, map[string]interface{}{"notifications": map[string]interface{}{}})); err == nil {
+		t.Error("Expected error when no notification target is configured")
+	}
+}
+
+// TestConfigRules tests that rules are decoded and validated, including their targets
+func TestConfigRules(t *testing.T) {
+	writeRules := func(rules interface{}) string {
+		t.Helper()
+		return writeConfig(t, map[string]interface{}{
+			"notifications": map[string]interface{}{
+				"ntfy_topic": "test-topic",
+				"targets": []map[string]interface{}{
+					{"type": "webhook", "name": "hook", "url": "https://hooks.example.com"},
+				},
+			},
+			"rules": rules,
+		})
+	}
+
+	config, err := loadConfig(writeRules([]map[string]interface{}{
+		{"name": "oncall", "match": map[string]interface{}{"regex": "(?i)sev[12]", "hours": "22:00-07:00"}, "priority": "urgent", "targets": []string{"ntfy", "hook"}},
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(config.Rules) != 1 || config.Rules[0].Match.Regex == nil || config.Rules[0].Match.Hours.String() != "22:00-07:00" {
+		t.Errorf("Rules not decoded: %+v", config.Rules)
+	}
+
+	invalid := []interface{}{
+		[]map[string]interface{}{{"match": map[string]interface{}{"regex": "("}}},
+		[]map[string]interface{}{{"match": map[string]interface{}{"hours": "all night"}}},
+		[]map[string]interface{}{{"action": "snooze"}},
+		[]map[string]interface{}{{"targets": []string{"pager"}}},
+	}
+	for _, rules := range invalid {
+		if _, err := loadConfig(writeRules(rules)); err == nil {
+			t.Errorf("Expected error for rules %v", rules)
+		}
+	}
+}
+
+// TestRunRules tests the rules dry run output
+func TestRunRules(t *testing.T) {
+	config := &monitor.Config{}
+	config.Rules = []monitor.Rule{
+		{Name: "incidents", Match: monitor.RuleMatch{Conversations: []string{"#incidents"}}, Priority: "urgent", Targets: []string{"phone"}},
+	}
+
+	var out strings.Builder
+	if code := runRules(config, []string{"-from", "Alice", "-in", "#incidents", "-text", "api down"}, &out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "Matched rule incidents (rules[0])") || !strings.Contains(out.String(), "priority: urgent") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	runRules(config, []string{"-in", "#random", "-text", "lunch?"}, &out)
+	if !strings.Contains(out.String(), "No rule matched (1 rule(s) checked); default action: drop") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if code := runRules(config, []string{"-at", "noon"}, &out); code != 2 {
+		t.Errorf("Expected exit code 2 for invalid -at, got %d", code)
+	}
+}
+
+// TestConfigSchedule tests that the quiet hours schedule is decoded and validated
+func TestConfigSchedule(t *testing.T) {
+	config, err := loadConfig(writeConfig(t, map[string]interface{}{
+		"schedule": map[string]interface{}{
+			"timezone":    "Europe/Berlin",
+			"quiet_hours": []map[string]interface{}{{"days": []string{"sat", "sun"}}, {"hours": "22:00-07:00"}},
+			"holidays":    []string{"2026-12-25"},
+			"vips":        []string{"U1"},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if config.Schedule.Timezone.String() != "Europe/Berlin" || len(config.Schedule.QuietHours) != 2 {
+		t.Errorf("Schedule not decoded: %+v", config.Schedule)
+	}
+
+	for _, schedule := range []map[string]interface{}{
+		{"timezone": "Mars/Olympus_Mons"},
+		{"quiet_hours": []map[string]interface{}{{"days": []string{"someday"}}}},
+		{"holidays": []string{"christmas"}},
+	} {
+		if _, err := loadConfig(writeConfig(t, map[string]interface{}{"schedule": schedule})); err == nil {
+			t.Errorf("Expected error for schedule %v", schedule)
+		}
+	}
+}
+
+// TestConfigReloader tests that a valid config file replaces the running
+// configuration and an invalid one is rejected as a whole
+func TestConfigReloader(t *testing.T) {
+	// Each version of the config file gets its own path, which the reloader is pointed at
+	configVersion := func(pollInterval int, targets interface{}) string {
+		t.Helper()
+		return writeConfig(t, map[string]interface{}{
+			"slack": map[string]interface{}{
+				"xoxc_token":            "test-xoxc",
+				"xoxd_token":            "test-xoxd",
+				"poll_interval_seconds": pollInterval,
+			},
+			"notifications": map[string]interface{}{
+				"ntfy_topic": "test-topic",
+				"targets":    targets,
+			},
+		})
+	}
+
+	configPath := configVersion(60, nil)
+	config, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	reloader := &configReloader{
+		path:       configPath,
+		current:    config,
+		dispatcher: notification.NewDispatcher(&notification.Multi{}, 0),
+		monitor:    monitor.NewMonitor(nil, nil, nil, config),
+	}
+
+	reloader.path = configVersion(15, []map[string]interface{}{{"type": "webhook", "url": "https://hooks.example.com"}})
+	if err := reloader.reload(); err != nil {
+		t.Fatalf("Expected reload to succeed, got: %v", err)
+	}
+	if reloader.current.Slack.PollIntervalSecs != 15 {
+		t.Errorf("Expected poll interval 15, got %d", reloader.current.Slack.PollIntervalSecs)
+	}
+
+	// A target the registry rejects leaves everything as it was
+	reloader.path = configVersion(5, []map[string]interface{}{{"type": "carrier-pigeon"}})
+	if err := reloader.reload(); err == nil {
+		t.Error("Expected an error for an unknown target type")
+	}
+	if reloader.current.Slack.PollIntervalSecs != 15 {
+		t.Errorf("Expected poll interval to stay 15, got %d", reloader.current.Slack.PollIntervalSecs)
+	}
+}
+
+// stateStub serves a fixed state to commands that read it
+type stateStub struct {
+	state *monitor.State
+}
+
+func (s stateStub) Load() (*monitor.State, error)   { return s.state, nil }
+func (s stateStub) Save(state *monitor.State) error { return nil }
+
+// TestRunStatus tests the status output for saved state
+func TestRunStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ts := func(ago time.Duration) string { return fmt.Sprintf("%d.000000", now.Add(-ago).Unix()) }
+	state := &monitor.State{
+		LastChecked: map[string]string{"D1": ts(2 * time.Hour), "C1": ts(40 * 24 * time.Hour)},
+		Threads:     map[string]map[string]string{"C1": {"1.0": ts(10 * time.Minute)}},
+		Digest:      map[string][]monitor.Notification{"D1": {{Text: "fyi"}}},
+		Held:        []monitor.Notification{{Text: "late"}, {Text: "later"}},
+	}
+	config := &monitor.Config{}
+	config.Monitor.TieredPolling = true
+
+	var out strings.Builder
+	if code := runStatus(config, stateStub{state}, 3, now, &out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, out.String())
+	}
+	lines := strings.Split(out.String(), "\n")
+	if lines[0] != "Tracked conversations: 2" {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	// The thread reply makes C1 the most recently active
+	if !strings.Contains(lines[1], "C1") || !strings.Contains(lines[1], "hot") || !strings.Contains(lines[1], "(10m ago)") || !strings.Contains(lines[1], "1 watched thread(s)") {
+		t.Errorf("Unexpected line for C1: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "D1") || !strings.Contains(lines[2], "(2h ago)") {
+		t.Errorf("Unexpected line for D1: %q", lines[2])
+	}
+	for _, want := range []string{"Digest: 1 notification(s) buffered", "Held for quiet hours: 2 notification(s)", "Outbox: 3 undelivered notification(s)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestRunAuthAndList tests the auth and list commands against the fake Slack server
+func TestRunAuthAndList(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(slacktest.User{ID: server.UserID(), Name: "me", RealName: "Me Myself"})
+	server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	server.AddDM("D1", "U1")
+	client := slack.NewClient(slacktest.DefaultXoxcToken, slacktest.DefaultXoxdToken, slack.WithBaseURL(server.URL()))
+
+	var out strings.Builder
+	if code := runAuth(context.Background(), client, &out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, out.String())
+	}
+	if want := "Authenticated as Me Myself @me (" + server.UserID() + ")"; !strings.Contains(out.String(), want) {
+		t.Errorf("Expected %q in output:\n%s", want, out.String())
+	}
+
+	config := &monitor.Config{}
+	config.Monitor.DMsOnly = true
+	state := &monitor.State{LastChecked: map[string]string{}}
+	out.Reset()
+	if code := runList(context.Background(), monitor.NewMonitor(client, nil, nil, config), stateStub{state}, &out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "DM with Alice") || !strings.Contains(out.String(), "1 conversation(s)") {
+		t.Errorf("Unexpected list output:\n%s", out.String())
+	}
+
+	server.SetAuthError("invalid_auth")
+	out.Reset()
+	if code := runAuth(context.Background(), client, &out); code != 1 {
+		t.Errorf("Expected exit code 1 for invalid_auth, got %d", code)
+	}
+	if !strings.Contains(out.String(), "The session has expired") {
+		t.Errorf("Expected an expiry hint, got:\n%s", out.String())
+	}
+}
+
+// notifierFunc adapts a function to monitor.Notifier
+type notifierFunc func(monitor.Notification) error
+
+func (f notifierFunc) SendNotification(n monitor.Notification) error { return f(n) }
+
+// TestRunTestNotify tests that each target is tried and reported separately
+func TestRunTestNotify(t *testing.T) {
+	var sent []string
+	targets := &notification.Multi{}
+	targets.Add("phone", notifierFunc(func(n monitor.Notification) error {
+		sent = append(sent, n.String())
+		return nil
+	}))
+	targets.Add("hook", notifierFunc(func(n monitor.Notification) error {
+		return errors.New("connection refused")
+	}))
+
+	var out strings.Builder
+	if code := runTestNotify(targets, []string{"-text", "hello"}, &out); code != 1 {
+		t.Errorf("Expected exit code 1 with a failing target, got %d", code)
+	}
+	if !strings.Contains(out.String(), "phone: sent") || !strings.Contains(out.String(), "hook: failed: connection refused") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if len(sent) != 1 || sent[0] != "Slack Monitor test: hello" {
+		t.Errorf("Unexpected notifications %v", sent)
+	}
+
+	out.Reset()
+	if code := runTestNotify(targets, []string{"-target", "phone"}, &out); code != 0 {
+		t.Errorf("Expected exit code 0 for a working target, got %d: %s", code, out.String())
+	}
+}
+
+// TestResolveLocations tests the precedence of flags, environment, the legacy
+// directory and XDG defaults
+func TestResolveLocations(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(envConfigPath, "")
+	t.Setenv(envStateDir, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+
+	check := func(name string, loc locations, wantConfig, wantState string) {
+		t.Helper()
+		if loc.configPath != wantConfig || loc.stateDir != wantState {
+			t.Errorf("%s: got config %q, state %q; want %q, %q", name, loc.configPath, loc.stateDir, wantConfig, wantState)
+		}
+	}
+
+	loc, err := resolveLocations("", "")
+	if err != nil {
+		t.Fatalf("resolveLocations failed: %v", err)
+	}
+	check("XDG defaults", loc, filepath.Join(home, ".config", "slack-monitor", "config.json"), filepath.Join(home, ".local", "state", "slack-monitor"))
+
+	t.Setenv("XDG_CONFIG_HOME", "/etc/xdg")
+	t.Setenv("XDG_STATE_HOME", "relative/ignored")
+	loc, _ = resolveLocations("", "")
+	check("XDG variables", loc, "/etc/xdg/slack-monitor/config.json", filepath.Join(home, ".local", "state", "slack-monitor"))
+
+	legacy := filepath.Join(home, ".slack-monitor")
+	if err := os.MkdirAll(legacy, 0700); err != nil {
+		t.Fatalf("Failed to create legacy dir: %v", err)
+	}
+	loc, _ = resolveLocations("", "")
+	check("legacy directory", loc, filepath.Join(legacy, "config.json"), legacy)
+
+	t.Setenv(envConfigPath, "/etc/slack-monitor/work.json")
+	t.Setenv(envStateDir, "/var/lib/slack-monitor")
+	loc, _ = resolveLocations("", "")
+	check("environment", loc, "/etc/slack-monitor/work.json", "/var/lib/slack-monitor")
+
+	loc, _ = resolveLocations("personal.json", "/tmp/personal")
+	check("flags", loc, "personal.json", "/tmp/personal")
+}
+
+// TestConfigEnvOverrides tests that SLACK_MONITOR_* variables override the config file
+func TestConfigEnvOverrides(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data, _ := json.Marshal(map[string]interface{}{
+		"slack": map[string]interface{}{
+			"xoxd_token":            "file-xoxd",
+			"poll_interval_seconds": 30,
+		},
+		"notifications": map[string]interface{}{
+			"ntfy_topic": "file-topic",
+		},
+	})
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// The file has no xoxc token, so loading needs the environment's
+	t.Setenv("SLACK_MONITOR_SLACK_XOXC_TOKEN", "env-xoxc")
+	t.Setenv("SLACK_MONITOR_SLACK_POLL_INTERVAL_SECONDS", "90")
+	t.Setenv("SLACK_MONITOR_SLACK_TLS_INSECURE_SKIP_VERIFY", "true")
+	t.Setenv("SLACK_MONITOR_MONITOR_DMS_ONLY", "false")
+	t.Setenv("SLACK_MONITOR_MONITOR_CONVERSATION_TYPES", "im, mpim")
+	t.Setenv("SLACK_MONITOR_DIGEST_SCHEDULE", "@daily")
+	t.Setenv("SLACK_MONITOR_SCHEDULE_TIMEZONE", "Europe/Berlin")
+	t.Setenv("SLACK_MONITOR_SCHEDULE_VIPS", `["U1", "Alice"]`)
+	t.Setenv("SLACK_MONITOR_RULES", `[{"name": "mute bots", "match": {"senders": ["U2"]}, "action": "drop"}]`)
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if config.Slack.XoxcToken != "env-xoxc" || config.Slack.XoxdToken != "file-xoxd" {
+		t.Errorf("Expected env xoxc and file xoxd tokens, got %q, %q", config.Slack.XoxcToken, config.Slack.XoxdToken)
+	}
+	if config.Slack.PollIntervalSecs != 90 || !config.Slack.TLS.InsecureSkipVerify {
+		t.Errorf("Slack overrides not applied: %+v", config.Slack)
+	}
+	if config.Monitor.DMsOnly || strings.Join(config.Monitor.ConversationTypes, ",") != "im,mpim" {
+		t.Errorf("Monitor overrides not applied: dms_only %v, types %v", config.Monitor.DMsOnly, config.Monitor.ConversationTypes)
+	}
+	if config.Digest.Schedule == nil || config.Schedule.Timezone == nil || strings.Join(config.Schedule.VIPs, ",") != "U1,Alice" {
+		t.Errorf("Schedule overrides not applied: digest %v, schedule %+v", config.Digest.Schedule, config.Schedule)
+	}
+	if len(config.Rules) != 1 || config.Rules[0].Name != "mute bots" {
+		t.Errorf("Rules override not applied: %+v", config.Rules)
+	}
+
+	t.Setenv("SLACK_MONITOR_SLACK_POLL_INTERVAL_SECONDS", "soon")
+	if _, err := loadConfig(configPath); err == nil || !strings.Contains(err.Error(), "SLACK_MONITOR_SLACK_POLL_INTERVAL_SECONDS") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Environment variables locating the config file and state directory when the
+// --config and --state-dir flags are not given
+const (
+	envConfigPath = "SLACK_MONITOR_CONFIG"
+	envStateDir   = "SLACK_MONITOR_STATE_DIR"
+)
+
+// legacyDir is the directory under $HOME that held both config and state
+// before XDG support. It is still used for both when it exists.
+const legacyDir = ".slack-monitor"
+
+// locations says where the config file and the state and outbox files are
+type locations struct {
+	configPath string
+	stateDir   string
+}
+
+// resolveLocations picks the config file and state directory. Each comes from
+// its flag if set, then its environment variable, then ~/.slack-monitor if that
+// directory exists, and otherwise the XDG base directories:
+// $XDG_CONFIG_HOME/slack-monitor/config.json (default ~/.config) and
+// $XDG_STATE_HOME/slack-monitor (default ~/.local/state).
+func resolveLocations(configFlag, stateDirFlag string) (locations, error) {
+	loc := locations{
+		configPath: firstNonEmpty(configFlag, os.Getenv(envConfigPath)),
+		stateDir:   firstNonEmpty(stateDirFlag, os.Getenv(envStateDir)),
+	}
+	if loc.configPath != "" && loc.stateDir != "" {
+		return loc, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return locations{}, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	legacy := filepath.Join(home, legacyDir)
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		loc.configPath = firstNonEmpty(loc.configPath, filepath.Join(legacy, "config.json"))
+		loc.stateDir = firstNonEmpty(loc.stateDir, legacy)
+		return loc, nil
+	}
+
+	loc.configPath = firstNonEmpty(loc.configPath, filepath.Join(xdgDir("XDG_CONFIG_HOME", home, ".config"), "slack-monitor", "config.json"))
+	loc.stateDir = firstNonEmpty(loc.stateDir, filepath.Join(xdgDir("XDG_STATE_HOME", home, ".local", "state"), "slack-monitor"))
+	return loc, nil
+}
+
+// xdgDir returns the XDG base directory named by env, or its default under
+// home. Relative paths are ignored, as the XDG specification requires.
+func xdgDir(env, home string, defaultPath ...string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(append([]string{home}, defaultPath...)...)
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+	"github.com/FourPalms/golang-slack-monitor/notification"
+)
+
+// configCheckInterval is how often the config file is checked for changes
+const configCheckInterval = 5 * time.Second
+
+// configReloader applies changes to the config file to the running monitor
+// and notification dispatcher
+type configReloader struct {
+	path       string // Config file
+	current    *monitor.Config
+	dispatcher *notification.Dispatcher
+	monitor    *monitor.Monitor
+}
+
+// watch reloads the configuration on SIGHUP and whenever the config file's
+// modification time changes, until ctx is cancelled
+func (r *configReloader) watch(ctx context.Context, hangup <-chan os.Signal) {
+	modTime := fileModTime(r.path)
+	ticker := time.NewTicker(configCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			log.Println("Received SIGHUP, reloading configuration")
+		case <-ticker.C:
+			if fileModTime(r.path).Equal(modTime) {
+				continue
+			}
+			log.Println("Config file changed, reloading configuration")
+		}
+		modTime = fileModTime(r.path)
+		if err := r.reload(); err != nil {
+			log.Printf("Keeping current configuration: %v", err)
+		}
+	}
+}
+
+// reload loads and validates the config file, with environment overrides as at
+// startup, then swaps the new settings in.
+// Nothing changes if any part of the new configuration is invalid.
+func (r *configReloader) reload() error {
+	config, err := loadConfig(r.path)
+	if err != nil {
+		return err
+	}
+	if _, err := slackClientOptions(config); err != nil {
+		return fmt.Errorf("invalid Slack client settings: %w", err)
+	}
+	targets, err := notification.NewFromConfig(config)
+	if err != nil {
+		return fmt.Errorf("invalid notification settings: %w", err)
+	}
+
+	if slackConnectionChanged(r.current, config) {
+		log.Println("Slack connection settings (api_base_url, proxy_url, tls, max_pages) changed; restart to apply them")
+	}
+	if config.Monitor.Realtime != r.current.Monitor.Realtime {
+		log.Println("monitor.realtime changed; restart to apply it")
+	}
+
+	// The targets switch together with the rules that route to them, unless
+	// the monitor rejects new Slack tokens
+	r.monitor.Reload(config, func() {
+		r.dispatcher.SetNotifier(targets, config.Notifications.MaxPerMinute)
+		log.Printf("Sending notifications to %d target(s)", targets.Len())
+	})
+	r.current = config
+	return nil
+}
+
+// slackConnectionChanged reports whether settings only read when the Slack
+// client is created differ between two configurations
+func slackConnectionChanged(old, new *monitor.Config) bool {
+	return old.Slack.APIBaseURL != new.Slack.APIBaseURL ||
+		old.Slack.ProxyURL != new.Slack.ProxyURL ||
+		old.Slack.TLS != new.Slack.TLS ||
+		old.Slack.MaxPages != new.Slack.MaxPages
+}
+
+// fileModTime returns the file's modification time, or the zero time if it
+// can't be read
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// runRules evaluates the configured rules against a message described by flags
+// and prints which rule matched and what would happen, without contacting Slack.
+// It returns the process exit code.
+func runRules(config *monitor.Config, args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("rules", flag.ContinueOnError)
+	fs.SetOutput(out)
+	from := fs.String("from", "U0000000000", "sender user ID or display name")
+	in := fs.String("in", "", "conversation ID or #channel (empty for a DM)")
+	convType := fs.String("type", "", "conversation type (default im, or public_channel with -in #name)")
+	text := fs.String("text", "", "message text")
+	mention := fs.String("mention", "", `how the message mentions you ("you", "@here", "@oncall")`)
+	thread := fs.Bool("thread", false, "the message is a thread reply")
+	at := fs.String("at", "", `when the message was posted ("15:04" today or "2006-01-02 15:04"; default now)`)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	posted := time.Now()
+	if *at != "" {
+		var err error
+		if posted, err = parseDryRunTime(*at, posted); err != nil {
+			fmt.Fprintf(out, "Invalid -at: %v\n", err)
+			return 2
+		}
+	}
+
+	n := monitor.Notification{
+		Sender:           *from,
+		SenderID:         *from,
+		ConversationType: *convType,
+		Text:             *text,
+		Timestamp:        fmt.Sprintf("%d.000000", posted.Unix()),
+		IsMention:        *mention != "",
+		Mention:          *mention,
+		Priority:         monitor.PriorityDefault,
+	}
+	if *in != "" && !strings.HasPrefix(*in, "D") {
+		n.Channel = "#" + strings.TrimPrefix(*in, "#")
+	}
+	n.ConversationID = strings.TrimPrefix(*in, "#")
+	if n.ConversationType == "" {
+		n.ConversationType = monitor.ConversationTypeIM
+		if n.Channel != "" {
+			n.ConversationType = monitor.ConversationTypePublicChannel
+		}
+	}
+	if *thread {
+		n.ThreadTS = fmt.Sprintf("%d.000000", posted.Unix()-1)
+	}
+
+	fmt.Fprintf(out, "Message: %s\n", n)
+	i := monitor.MatchRule(config.Rules, n)
+	if i < 0 {
+		// Channel messages notify by default only if they mention you or reply in a watched thread
+		isChannel := n.ConversationType == monitor.ConversationTypePrivateChannel || n.ConversationType == monitor.ConversationTypePublicChannel
+		action := config.DefaultAction(n, !isChannel || n.IsMention || n.IsThreadReply())
+		fmt.Fprintf(out, "No rule matched (%d rule(s) checked); default action: %s\n", len(config.Rules), action)
+		if action == monitor.RuleActionNotify {
+			printSchedule(config, n, posted, out)
+		}
+		return 0
+	}
+
+	rule := config.Rules[i]
+	n = rule.Apply(n)
+	fmt.Fprintf(out, "Matched rule %s (rules[%d])\n", rule.DisplayName(i), i)
+	fmt.Fprintf(out, "  action:   %s\n", rule.ActionName())
+	fmt.Fprintf(out, "  priority: %s\n", n.Priority)
+	if len(n.Targets) > 0 {
+		fmt.Fprintf(out, "  targets:  %s\n", strings.Join(n.Targets, ", "))
+	} else {
+		fmt.Fprintf(out, "  targets:  all\n")
+	}
+	if len(n.Tags) > 0 {
+		fmt.Fprintf(out, "  tags:     %s\n", strings.Join(n.Tags, ", "))
+	}
+	if rule.ActionName() == monitor.RuleActionNotify {
+		printSchedule(config, n, posted, out)
+	}
+	return 0
+}
+
+// printSchedule reports whether quiet hours would hold a notification posted at t
+func printSchedule(config *monitor.Config, n monitor.Notification, t time.Time, out io.Writer) {
+	switch {
+	case !config.Schedule.IsQuiet(t):
+	case config.Schedule.Holds(n, t):
+		fmt.Fprintln(out, "Quiet hours: held until the quiet period ends")
+	default:
+		fmt.Fprintln(out, "Quiet hours: breaks through (VIP or urgent)")
+	}
+}
+
+// parseDryRunTime parses "15:04" (on the day of now) or "2006-01-02 15:04" in local time
+func parseDryRunTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("15:04", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not \"15:04\" or \"2006-01-02 15:04\"", s)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local), nil
+}
@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultConversationTypes are monitored when dms_only is false and no types are configured.
+// Public channels are opt-in because a workspace can have thousands of them.
+var DefaultConversationTypes = []string{
+	ConversationTypeIM,
+	ConversationTypeMPIM,
+	ConversationTypePrivateChannel,
+}
+
+// fetchConversations returns the conversations to monitor according to the config
+func (m *Monitor) fetchConversations(ctx context.Context) ([]Conversation, error) {
+	if m.config.Monitor.DMsOnly {
+		return m.slackClient.GetDMConversations(ctx)
+	}
+
+	types := m.config.Monitor.ConversationTypes
+	if len(types) == 0 {
+		types = DefaultConversationTypes
+	}
+
+	conversations, err := m.slackClient.GetConversations(ctx, types)
+	if err != nil {
+		return nil, err
+	}
+	return filterConversations(conversations, m.config.Monitor.Filters), nil
+}
+
+// ConversationSummary describes a monitored conversation, for listing
+type ConversationSummary struct {
+	Conversation
+	Label        string    // Readable name: "DM with Alice", "#deploys" or "group DM (alice, bob)"
+	Tier         string    // Polling tier according to state (one of the Tier* constants)
+	LastActivity time.Time // Newest message seen in state; zero if never checked
+}
+
+// ListConversations returns the conversations the monitor checks, skipping
+// DMs with deleted users, with resolved names and the polling tier the state
+// puts them in
+func (m *Monitor) ListConversations(ctx context.Context, state *State) ([]ConversationSummary, error) {
+	conversations, err := m.fetchConversations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	summaries := make([]ConversationSummary, 0, len(conversations))
+	for _, conv := range conversations {
+		if conv.IsUserDeleted {
+			continue
+		}
+		label := channelLabel(conv)
+		if conv.IsDM() {
+			label = "DM with " + m.getUserDisplayName(ctx, conv.User)
+		}
+		summaries = append(summaries, ConversationSummary{
+			Conversation: conv,
+			Label:        label,
+			Tier:         m.tier(conv.ID, state, now),
+			LastActivity: state.LastActivity(conv.ID),
+		})
+	}
+	return summaries, nil
+}
+
+// filterConversations applies the per-type include/exclude lists.
+// Public channels without an include list are limited to channels the user has joined.
+func filterConversations(conversations []Conversation, filters map[string]ConversationFilter) []Conversation {
+	selected := make([]Conversation, 0, len(conversations))
+	for _, conv := range conversations {
+		filter := filters[conv.Type]
+
+		if len(filter.Include) > 0 {
+			if !matchesAny(conv, filter.Include) {
+				continue
+			}
+		} else if conv.Type == ConversationTypePublicChannel && !conv.IsMember {
+			continue
+		}
+
+		if matchesAny(conv, filter.Exclude) {
+			continue
+		}
+		selected = append(selected, conv)
+	}
+	return selected
+}
+
+// matchesAny reports whether a conversation matches any filter entry by
+// channel ID, channel name (with or without "#") or DM user ID
+func matchesAny(conv Conversation, entries []string) bool {
+	for _, entry := range entries {
+		entry = strings.TrimPrefix(strings.TrimSpace(entry), "#")
+		if entry == "" {
+			continue
+		}
+		if entry == conv.ID || (conv.Name != "" && strings.EqualFold(entry, conv.Name)) || (conv.User != "" && entry == conv.User) {
+			return true
+		}
+	}
+	return false
+}
+
+// channelLabel returns a human-readable name for a non-DM conversation
+func channelLabel(conv Conversation) string {
+	switch {
+	case conv.Type == ConversationTypeMPIM:
+		return "group DM (" + mpimMembers(conv.Name) + ")"
+	case conv.Name != "":
+		return "#" + conv.Name
+	default:
+		return conv.ID
+	}
+}
+
+// mpimMembers turns a group DM name like "mpdm-alice--bob--carol-1" into "alice, bob, carol"
+func mpimMembers(name string) string {
+	trimmed := strings.TrimPrefix(name, "mpdm-")
+	if i := strings.LastIndex(trimmed, "-"); i > 0 && !strings.HasSuffix(trimmed[:i], "-") {
+		trimmed = trimmed[:i]
+	}
+	members := strings.Split(trimmed, "--")
+	if len(members) == 0 || trimmed == "" {
+		return name
+	}
+	return strings.Join(members, ", ")
+}
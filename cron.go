@@ -0,0 +1,148 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronFieldRanges are the bounds of the five cron fields: minute, hour, day of
+// month, month and day of week (0 = Sunday; 7 is accepted as Sunday too)
+var cronFieldRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// cronAliases are the accepted "@" shorthands
+var cronAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// CronSchedule is a five-field cron expression ("minute hour day month weekday")
+// decoded from a JSON string. Fields accept "*", numbers, ranges ("9-17"),
+// lists ("8,12,17") and steps ("*/15", "9-17/2"), as well as the @hourly,
+// @daily, @weekly and @monthly shorthands.
+type CronSchedule struct {
+	expr   string
+	fields [5]map[int]bool
+	anyDay [2]bool // Whether day of month and day of week are "*"
+}
+
+// ParseCron parses a cron expression
+func ParseCron(expr string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if alias, ok := cronAliases[spec]; ok {
+		spec = alias
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q (want 5 fields or @hourly/@daily/@weekly/@monthly)", expr)
+	}
+
+	c := &CronSchedule{expr: expr}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFieldRanges[i][0], cronFieldRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		c.fields[i] = values
+	}
+	if c.fields[4][7] {
+		c.fields[4][0] = true
+	}
+	c.anyDay = [2]bool{parts[2] == "*", parts[4] == "*"}
+	return c, nil
+}
+
+// UnmarshalText parses a cron expression
+func (c *CronSchedule) UnmarshalText(text []byte) error {
+	parsed, err := ParseCron(string(text))
+	if err != nil {
+		return err
+	}
+	*c = *parsed
+	return nil
+}
+
+// String returns the expression as written
+func (c CronSchedule) String() string {
+	return c.expr
+}
+
+// Next returns the first whole minute strictly after t that matches the
+// schedule, in t's location, or the zero time if none does within five years
+func (c CronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		switch {
+		case !c.fields[3][int(next.Month())]:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !c.matchesDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !c.fields[1][next.Hour()]:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case !c.fields[0][next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay applies cron's day rule: if both day of month and day of week are
+// restricted, either may match
+func (c CronSchedule) matchesDay(t time.Time) bool {
+	dom := c.fields[2][t.Day()]
+	dow := c.fields[4][int(t.Weekday())]
+	switch {
+	case c.anyDay[0] && c.anyDay[1]:
+		return true
+	case c.anyDay[0]:
+		return dow
+	case c.anyDay[1]:
+		return dom
+	}
+	return dom || dow
+}
+
+// parseCronField expands one comma-separated field into the set of values it matches
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(loPart); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiPart); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
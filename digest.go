@@ -0,0 +1,162 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDigestSchedule = "@hourly" // When digests are sent unless digest.schedule is set
+	digestSnippetLength   = 100       // Runes of each message shown in a digest
+)
+
+// DefaultAction returns what happens to n when no rule matches it: messages
+// that warrant a notification on their own (notify) are sent, or buffered if
+// their conversation is in digest mode; everything else is dropped
+func (c *Config) DefaultAction(n Notification, notify bool) string {
+	switch {
+	case !notify:
+		return RuleActionDrop
+	case len(c.Digest.Conversations) > 0 && matchesConversation(n, c.Digest.Conversations):
+		return RuleActionDigest
+	}
+	return RuleActionNotify
+}
+
+// addToDigest buffers n for the next digest of its conversation
+func (m *Monitor) addToDigest(n Notification, state *State) {
+	if state.Digest == nil {
+		state.Digest = make(map[string][]Notification)
+	}
+	state.Digest[n.ConversationID] = append(state.Digest[n.ConversationID], n)
+}
+
+// digestSchedule returns the configured digest schedule, or hourly
+func (m *Monitor) digestSchedule() *CronSchedule {
+	if m.config.Digest.Schedule != nil {
+		return m.config.Digest.Schedule
+	}
+	schedule, _ := ParseCron(defaultDigestSchedule)
+	return schedule
+}
+
+// flushDigest sends everything buffered for the digest as one notification
+// when the digest schedule comes due. If sending fails, the buffer is kept for
+// the next cycle. Digests wait out quiet hours.
+func (m *Monitor) flushDigest(state *State) {
+	now := time.Now().In(m.config.Schedule.location())
+	if state.LastDigest.IsZero() {
+		state.LastDigest = now
+		return
+	}
+	if due := m.digestSchedule().Next(state.LastDigest.In(now.Location())); due.IsZero() || due.After(now) {
+		return
+	}
+	if len(state.Digest) == 0 {
+		state.LastDigest = now
+		return
+	}
+	if m.config.Schedule.IsQuiet(now) {
+		return
+	}
+
+	var failed []Notification
+	for _, digest := range buildDigests(state.Digest) {
+		if err := m.notifier.SendNotification(digest); err != nil {
+			log.Printf("Failed to send digest of %d message(s): %v", len(digest.Batch), err)
+			failed = append(failed, digest.Batch...)
+			continue
+		}
+		log.Printf("Sent digest of %d message(s)", len(digest.Batch))
+	}
+	// Digests that failed are sent again next cycle; the rest are done
+	state.Digest = nil
+	for _, n := range failed {
+		m.addToDigest(n, state)
+	}
+	if len(failed) == 0 {
+		state.LastDigest = now
+	}
+}
+
+// buildDigests builds one digest per set of targets the buffered notifications
+// were routed to, so a rule's targets apply to its digest too
+func buildDigests(buffer map[string][]Notification) []Notification {
+	var all []Notification
+	for _, entries := range buffer {
+		all = append(all, entries...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return compareTimestamps(all[i].Timestamp, all[j].Timestamp) < 0
+	})
+
+	var digests []Notification
+	for _, group := range GroupByTargets(all) {
+		groupBuffer := make(map[string][]Notification)
+		for _, n := range group {
+			groupBuffer[n.ConversationID] = append(groupBuffer[n.ConversationID], n)
+		}
+		digests = append(digests, buildDigest(groupBuffer))
+	}
+	return digests
+}
+
+// buildDigest summarizes buffered notifications with the same targets into
+// one, listing each conversation with who wrote and a snippet of every
+// message, e.g.
+//
+//	DM with Alice (2)
+//	• are you around?
+//	• never mind, found it
+func buildDigest(buffer map[string][]Notification) Notification {
+	// Conversations in order of their first buffered message
+	ids := make([]string, 0, len(buffer))
+	for id := range buffer {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return compareTimestamps(buffer[ids[i]][0].Timestamp, buffer[ids[j]][0].Timestamp) < 0
+	})
+
+	var all []Notification
+	var sections []string
+	for _, id := range ids {
+		entries := buffer[id]
+		lines := []string{fmt.Sprintf("%s (%d)", entries[0].ConversationName(), len(entries))}
+		for _, n := range entries {
+			snippet := snippet(n.Text)
+			if !n.IsDM() {
+				snippet = n.Sender + ": " + snippet
+			}
+			lines = append(lines, "• "+snippet)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+		all = append(all, entries...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return compareTimestamps(all[i].Timestamp, all[j].Timestamp) < 0
+	})
+
+	digest := Summarize(all)
+	if len(all) == 1 {
+		// Summarize returns a lone notification as is; keep it a digest
+		digest.Batch = all
+	}
+	digest.ID = "digest/" + all[len(all)-1].DeliveryID()
+	digest.Text = strings.Join(sections, "\n")
+	digest.Tags = append(append([]string(nil), digest.Tags...), "digest")
+	return digest
+}
+
+// snippet shortens text to a single line of at most digestSnippetLength runes
+func snippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) > digestSnippetLength {
+		return string(runes[:digestSnippetLength-1]) + "…"
+	}
+	return text
+}
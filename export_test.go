@@ -0,0 +1,9 @@
+package monitor
+
+import "time"
+
+// SetPollInterval makes c poll every d, which unlike poll_interval_seconds
+// may be under a second, so integration tests don't wait whole seconds
+func (c *Config) SetPollInterval(d time.Duration) {
+	c.pollIntervalOverride = d
+}
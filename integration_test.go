@@ -0,0 +1,835 @@
+package monitor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+	"github.com/FourPalms/golang-slack-monitor/slack"
+	"github.com/FourPalms/golang-slack-monitor/slacktest"
+)
+
+// recordingNotifier captures notifications and signals each one on a channel
+type recordingNotifier struct {
+	mu            sync.Mutex
+	messages      []string
+	notifications []monitor.Notification
+	sent          chan string
+	failures      int // Number of upcoming sends to reject
+}
+
+func newRecordingNotifier() *recordingNotifier {
+	return &recordingNotifier{sent: make(chan string, 100)}
+}
+
+func (n *recordingNotifier) SendNotification(notification monitor.Notification) error {
+	message := notification.String()
+	n.mu.Lock()
+	if n.failures > 0 {
+		n.failures--
+		n.mu.Unlock()
+		return errors.New("notifier unavailable")
+	}
+	n.messages = append(n.messages, message)
+	n.notifications = append(n.notifications, notification)
+	n.mu.Unlock()
+	n.sent <- message
+	return nil
+}
+
+// memoryStore keeps state in memory and signals after every save
+type memoryStore struct {
+	mu    sync.Mutex
+	state *monitor.State
+	saved chan struct{} // Holds one signal until it is taken; later saves don't block on it
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{saved: make(chan struct{}, 1)}
+}
+
+func (s *memoryStore) Load() (*monitor.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return &monitor.State{LastChecked: make(map[string]string)}, nil
+	}
+	return s.state, nil
+}
+
+func (s *memoryStore) Save(state *monitor.State) error {
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+	select {
+	case s.saved <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// testPollInterval is how often the harness's monitor polls; waits are
+// signalled, so tests spend little time idle between cycles
+const testPollInterval = 20 * time.Millisecond
+
+// harness wires a Monitor to a fake Slack server with in-memory dependencies
+type harness struct {
+	server   *slacktest.Server
+	notifier *recordingNotifier
+	store    *memoryStore
+	monitor  *monitor.Monitor
+	config   *monitor.Config
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	server := slacktest.NewServer()
+	t.Cleanup(server.Close)
+
+	config := &monitor.Config{}
+	config.Slack.XoxcToken = slacktest.DefaultXoxcToken
+	config.Slack.XoxdToken = slacktest.DefaultXoxdToken
+	config.SetPollInterval(testPollInterval)
+	config.Monitor.DMsOnly = true
+
+	h := &harness{
+		server:   server,
+		notifier: newRecordingNotifier(),
+		store:    newMemoryStore(),
+		config:   config,
+	}
+	h.monitor = monitor.NewMonitor(h.newClient(), h.notifier, h.store, config)
+	return h
+}
+
+// newClient returns a Slack client for the fake server. The fake has no rate
+// limits, so neither does the client.
+func (h *harness) newClient(opts ...slack.Option) *slack.Client {
+	opts = append([]slack.Option{slack.WithBaseURL(h.server.URL()), slack.WithoutRateLimit()}, opts...)
+	return slack.NewClient(h.config.Slack.XoxcToken, h.config.Slack.XoxdToken, opts...)
+}
+
+// run starts the monitor in the background and returns a function that stops it
+func (h *harness) run(t *testing.T) (stop func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- h.monitor.Run(ctx) }()
+	return func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("Monitor did not stop after cancellation")
+			return nil
+		}
+	}
+}
+
+// waitForCycle blocks until the monitor next saves state. A save from before
+// the call doesn't count; the cycle saving may have started before the call,
+// so wait twice for a cycle that starts after it.
+func (h *harness) waitForCycle(t *testing.T) {
+	t.Helper()
+	select {
+	case <-h.store.saved:
+	default:
+	}
+	select {
+	case <-h.store.saved:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a check cycle")
+	}
+}
+
+// waitForNotification blocks until a notification is sent and returns it
+func (h *harness) waitForNotification(t *testing.T) string {
+	t.Helper()
+	select {
+	case msg := <-h.notifier.sent:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a notification")
+		return ""
+	}
+}
+
+// TestRunNotifiesOnNewDM tests the full poll cycle against the fake Slack server
+func TestRunNotifiesOnNewDM(t *testing.T) {
+	h := newHarness(t)
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice Smith"})
+	h.server.AddDM("D1", "U1")
+
+	// Messages from before the first check are not replayed
+	h.server.AddMessage("D1", slacktest.Message{User: "U1", Text: "old message", Timestamp: fmt.Sprintf("%d.000000", time.Now().Add(-time.Minute).Unix())})
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	h.server.PostMessage("D1", "U1", "are you there?")
+	h.server.PostMessage("D1", h.server.UserID(), "my own reply")
+
+	if got := h.waitForNotification(t); got != "DM from Alice Smith: are you there?" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	h.waitForCycle(t)
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(h.notifier.messages) != 1 {
+		t.Fatalf("Expected exactly 1 notification, got %v", h.notifier.messages)
+	}
+
+	n := h.notifier.notifications[0]
+	if n.SenderID != "U1" || n.ConversationID != "D1" || n.TeamID != slacktest.DefaultTeamID || !n.IsDM() {
+		t.Errorf("Unexpected notification fields %+v", n)
+	}
+	if want := "https://test.slack.com/archives/D1/p" + strings.Replace(n.Timestamp, ".", "", 1); n.Permalink != want {
+		t.Errorf("Expected permalink %s, got %s", want, n.Permalink)
+	}
+}
+
+// TestRunRetriesFailedNotifications tests that a message is not skipped when it cannot be enqueued
+func TestRunRetriesFailedNotifications(t *testing.T) {
+	h := newHarness(t)
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddDM("D1", "U1")
+	h.notifier.failures = 1
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	h.server.PostMessage("D1", "U1", "did you get this?")
+	if got := h.waitForNotification(t); got != "DM from Alice: did you get this?" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if h.notifier.failures != 0 {
+		t.Errorf("Expected the first send to fail, %d failures left", h.notifier.failures)
+	}
+}
+
+// TestRunSkipsDeletedUsers tests that conversations with deleted users are never fetched
+func TestRunSkipsDeletedUsers(t *testing.T) {
+	h := newHarness(t)
+	h.server.AddConversation(slacktest.Conversation{ID: "D1", User: "U1", IsUserDeleted: true})
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if got := h.server.RequestCount("conversations.history"); got != 0 {
+		t.Errorf("Expected no history requests for deleted user DM, got %d", got)
+	}
+}
+
+// TestRunMonitorsChannels tests channel mode with include/exclude filters
+func TestRunMonitorsChannels(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.DMsOnly = false
+	h.config.Monitor.ConversationTypes = []string{monitor.ConversationTypeIM, monitor.ConversationTypePublicChannel}
+	h.config.Monitor.Filters = map[string]monitor.ConversationFilter{
+		monitor.ConversationTypePublicChannel: {Include: []string{"#deploys", "random"}, Exclude: []string{"random"}},
+	}
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddConversation(slacktest.Conversation{ID: "C1", Name: "deploys", Type: slacktest.TypePublicChannel})
+	h.server.AddConversation(slacktest.Conversation{ID: "C2", Name: "random", Type: slacktest.TypePublicChannel, IsMember: true})
+	h.server.AddConversation(slacktest.Conversation{ID: "G1", Name: "private", Type: slacktest.TypePrivateChannel})
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	h.server.PostMessage("C2", "U1", "<@UME> excluded channel")
+	h.server.PostMessage("G1", "U1", "<@UME> type not monitored")
+	h.server.PostMessage("C1", "U1", "<@UME> v1.2 is live")
+
+	if got := h.waitForNotification(t); got != "Alice mentioned you in #deploys: @me v1.2 is live" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	h.waitForCycle(t)
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(h.notifier.messages) != 1 {
+		t.Errorf("Expected exactly 1 notification, got %v", h.notifier.messages)
+	}
+}
+
+// TestRunNotifiesOnMentionsOnly tests that channel messages notify only when they mention the user
+func TestRunNotifiesOnMentionsOnly(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.DMsOnly = false
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddUserGroup(slacktest.UserGroup{ID: "S1", Handle: "oncall", Users: []string{"U1", slacktest.DefaultUserID}})
+	h.server.AddUserGroup(slacktest.UserGroup{ID: "S2", Handle: "design", Users: []string{"U1"}})
+	h.server.AddConversation(slacktest.Conversation{ID: "G1", Name: "eng", Type: slacktest.TypePrivateChannel})
+	h.server.AddConversation(slacktest.Conversation{ID: "G2", Name: "mpdm-alice--me-1", Type: slacktest.TypeMPIM})
+	h.server.AddDM("D1", "U1")
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	h.server.PostMessage("G1", "U1", "just chatting")
+	h.server.PostMessage("G1", "U1", "<@U2> not you")
+	h.server.PostMessage("G1", "U1", "<!subteam^S2|@design> not your group")
+	h.server.PostMessage("G1", "U1", "<!subteam^S1|@oncall> pager is firing")
+	h.server.PostMessage("G1", "U1", "<!here> standup")
+	h.server.PostMessage("G2", "U1", "group DMs always notify")
+	h.server.PostMessage("D1", "U1", "so do DMs")
+
+	want := map[string]bool{
+		"Alice mentioned @oncall in #eng: @oncall pager is firing": true,
+		"Alice mentioned @here in #eng: @here standup":             true,
+		"Alice in group DM (alice, me): group DMs always notify":   true,
+		"DM from Alice: so do DMs":                                 true,
+	}
+	for range want {
+		got := h.waitForNotification(t)
+		if !want[got] {
+			t.Errorf("Unexpected notification %q", got)
+		}
+	}
+	h.waitForCycle(t)
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(h.notifier.messages) != len(want) {
+		t.Errorf("Expected %d notifications, got %v", len(want), h.notifier.messages)
+	}
+}
+
+// TestRunNotifiesOnThreadReplies tests thread reply notifications in DMs and channels
+func TestRunNotifiesOnThreadReplies(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.DMsOnly = false
+	me := h.server.UserID()
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddUser(slacktest.User{ID: "U2", Name: "bob", RealName: "Bob"})
+	h.server.AddDM("D1", "U1")
+	h.server.AddConversation(slacktest.Conversation{ID: "G1", Name: "eng", Type: slacktest.TypePrivateChannel})
+	// Threads waiting for their first reply are checked at the warm interval
+	h.config.Monitor.Tiers.WarmIntervalSecs = 1
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	dmParent := h.server.PostMessage("D1", "U1", "quick question")
+	myParent := h.server.PostMessage("G1", me, "RFC: new deploy flow")
+	otherParent := h.server.PostMessage("G1", "U2", "lunch?")
+	if got := h.waitForNotification(t); got != "DM from Alice: quick question" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	h.waitForCycle(t)
+
+	h.server.PostReply("D1", dmParent, me, "sure, go ahead")
+	h.server.PostReply("D1", dmParent, "U1", "is the API frozen?")
+	h.server.PostReply("G1", myParent, "U2", "looks good to me")
+	h.server.PostReply("G1", otherParent, "U1", "yes please")
+
+	want := map[string]bool{
+		"Thread reply from Alice: is the API frozen?":     true,
+		"Bob replied in thread in #eng: looks good to me": true,
+	}
+	for range want {
+		if got := h.waitForNotification(t); !want[got] {
+			t.Errorf("Unexpected notification %q", got)
+		}
+	}
+	h.waitForCycle(t)
+
+	// Quiet cycle: watched threads without new replies cost no replies calls
+	repliesBefore := h.server.RequestCount("conversations.replies")
+	h.waitForCycle(t)
+	if got := h.server.RequestCount("conversations.replies"); got != repliesBefore {
+		t.Errorf("Expected no replies calls on a quiet cycle, got %d", got-repliesBefore)
+	}
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(h.notifier.messages) != 3 {
+		t.Errorf("Expected 3 notifications, got %v", h.notifier.messages)
+	}
+}
+
+// TestRunAppliesRules tests that rules drop, digest and reroute messages before dispatch
+func TestRunAppliesRules(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.DMsOnly = false
+	h.config.Rules = []monitor.Rule{
+		{Name: "mute bots", Match: monitor.RuleMatch{Senders: []string{"U2"}}, Action: monitor.RuleActionDrop},
+		{Name: "later", Match: monitor.RuleMatch{Keywords: []string{"fyi"}}, Action: monitor.RuleActionDigest},
+		{Name: "incidents", Match: monitor.RuleMatch{Conversations: []string{"#incidents"}}, Priority: "urgent", Targets: []string{"phone"}, Tags: []string{"rotating_light"}},
+	}
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddUser(slacktest.User{ID: "U2", Name: "ci", RealName: "CI Bot"})
+	h.server.AddDM("D1", "U1")
+	h.server.AddDM("D2", "U2")
+	h.server.AddConversation(slacktest.Conversation{ID: "G1", Name: "incidents", Type: slacktest.TypePrivateChannel})
+	h.server.AddConversation(slacktest.Conversation{ID: "G2", Name: "eng", Type: slacktest.TypePrivateChannel})
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	h.server.PostMessage("D2", "U2", "build passed")
+	h.server.PostMessage("D1", "U1", "fyi the office is closed friday")
+	h.server.PostMessage("G2", "U1", "no mention, no rule")
+	h.server.PostMessage("G1", "U1", "api is down")
+
+	if got := h.waitForNotification(t); got != "Alice in #incidents: api is down" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	h.waitForCycle(t)
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(h.notifier.notifications) != 1 {
+		t.Fatalf("Expected exactly 1 notification, got %v", h.notifier.messages)
+	}
+	n := h.notifier.notifications[0]
+	if n.Priority != monitor.PriorityUrgent || strings.Join(n.Targets, ",") != "phone" || strings.Join(n.Tags, ",") != "rotating_light" {
+		t.Errorf("Rule not applied: priority %s, targets %v, tags %v", n.Priority, n.Targets, n.Tags)
+	}
+	if held := h.store.state.Digest["D1"]; len(held) != 1 || held[0].Text != "fyi the office is closed friday" {
+		t.Errorf("Expected the fyi message to be held for the digest, got %+v", h.store.state.Digest)
+	}
+}
+
+// TestRunTieredPolling tests that idle conversations are polled less often than active ones
+func TestRunTieredPolling(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.TieredPolling = true
+	h.server.FailMethod("client.counts", "unknown_method") // Count history calls without change detection
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddUser(slacktest.User{ID: "U2", Name: "bob", RealName: "Bob"})
+	h.server.AddDM("D1", "U1")
+	h.server.AddDM("D2", "U2")
+
+	// D1 last saw a message 90 days ago (cold); D2 is new, so it starts hot
+	idleSince := time.Now().AddDate(0, 0, -90)
+	h.store.state = &monitor.State{LastChecked: map[string]string{"D1": fmt.Sprintf("%d.000000", idleSince.Unix())}}
+
+	stop := h.run(t)
+	for i := 0; i < 3; i++ {
+		h.waitForCycle(t)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	// Both on the first cycle, then only the hot DM
+	if got := h.server.RequestCount("conversations.history"); got != 4 {
+		t.Errorf("Expected 4 history requests over 3 cycles, got %d", got)
+	}
+}
+
+// TestRunNotifiesHistoryGap tests that messages cut off by the history page
+// cap are reported instead of skipped silently
+func TestRunNotifiesHistoryGap(t *testing.T) {
+	h := newHarness(t)
+	h.monitor = monitor.NewMonitor(h.newClient(slack.WithMaxPages(1)), h.notifier, h.store, h.config)
+	h.server.SetPageSize(2)
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddDM("D1", "U1")
+
+	// All four arrive since the last check, so one cycle sees them together
+	since := fmt.Sprintf("%d.000000", time.Now().Add(-time.Minute).Unix())
+	h.store.state = &monitor.State{LastChecked: map[string]string{"D1": since}}
+	for _, text := range []string{"one", "two", "three", "four"} {
+		h.server.PostMessage("D1", "U1", text)
+	}
+
+	stop := h.run(t)
+	want := []string{
+		"Messages not shown in DM with Alice: More messages arrived since",
+		"DM from Alice: three",
+		"DM from Alice: four",
+	}
+	for _, prefix := range want {
+		if got := h.waitForNotification(t); !strings.HasPrefix(got, prefix) {
+			t.Errorf("Expected notification starting %q, got %q", prefix, got)
+		}
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+// TestRunSkipsUnchangedConversations tests that history is only fetched for
+// conversations client.counts reports as changed, and for all when it fails
+func TestRunSkipsUnchangedConversations(t *testing.T) {
+	h := newHarness(t)
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	for _, id := range []string{"D1", "D2", "D3"} {
+		h.server.AddDM(id, "U1")
+	}
+
+	stop := h.run(t)
+	h.waitForCycle(t) // First sight of each conversation: 3 history calls
+	h.waitForCycle(t) // Nothing new: no history calls
+
+	h.server.PostMessage("D2", "U1", "anything new?")
+	if got := h.waitForNotification(t); got != "DM from Alice: anything new?" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	h.waitForCycle(t)
+	if got := h.server.RequestCount("conversations.history"); got != 4 {
+		t.Errorf("Expected 4 history requests (3 initial + 1 changed), got %d", got)
+	}
+
+	// The new message is watched for replies, but that alone doesn't refetch
+	// its conversation every cycle
+	for i := 0; i < 3; i++ {
+		h.waitForCycle(t)
+	}
+	if got := h.server.RequestCount("conversations.history"); got != 4 {
+		t.Errorf("Expected no history requests on idle cycles, got %d", got-4)
+	}
+
+	h.server.FailMethod("client.counts", "unknown_method")
+	h.waitForCycle(t)
+	h.waitForCycle(t)
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if got := h.server.RequestCount("conversations.history"); got < 7 {
+		t.Errorf("Expected every conversation fetched once client.counts fails, got %d history requests", got)
+	}
+}
+
+// TestRunChecksConversationsConcurrently tests that a bounded worker pool
+// checks conversations at the same time, and keeps each conversation's
+// notifications in order
+func TestRunChecksConversationsConcurrently(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.Workers = 3
+	const dms = 6
+	h.store.state = &monitor.State{LastChecked: make(map[string]string)}
+	since := fmt.Sprintf("%d.000000", time.Now().Add(-time.Hour).Unix())
+	for i := 1; i <= dms; i++ {
+		id := fmt.Sprintf("D%d", i)
+		h.server.AddUser(slacktest.User{ID: fmt.Sprintf("U%d", i), Name: fmt.Sprintf("user%d", i)})
+		h.server.AddDM(id, fmt.Sprintf("U%d", i))
+		h.server.PostMessage(id, fmt.Sprintf("U%d", i), "first")
+		h.server.PostMessage(id, fmt.Sprintf("U%d", i), "second")
+		h.store.state.LastChecked[id] = since
+	}
+
+	const delay = 200 * time.Millisecond
+	h.server.Delay("conversations.history", delay)
+	start := time.Now()
+	if err := h.monitor.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= dms*delay/2 {
+		t.Errorf("Checking %d conversations took %s, expected well under %s", dms, elapsed, dms*delay)
+	}
+	if peak := h.server.PeakConcurrency("conversations.history"); peak < 2 || peak > h.config.Monitor.Workers {
+		t.Errorf("Expected between 2 and %d history requests at once, got %d", h.config.Monitor.Workers, peak)
+	}
+
+	seen := make(map[string][]string)
+	for _, n := range h.notifier.notifications {
+		seen[n.ConversationID] = append(seen[n.ConversationID], n.Text)
+	}
+	for i := 1; i <= dms; i++ {
+		id := fmt.Sprintf("D%d", i)
+		if got := strings.Join(seen[id], ","); got != "first,second" {
+			t.Errorf("Expected %s notified in order, got %q", id, got)
+		}
+	}
+	if got := len(h.store.state.LastChecked); got != dms {
+		t.Errorf("Expected %d conversations tracked, got %d", dms, got)
+	}
+}
+
+// waitForRealtime blocks until the monitor's real-time connection is up
+func (h *harness) waitForRealtime(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for h.server.RealtimeClients() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the real-time connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRunRealtime tests that messages arrive over the websocket without
+// waiting for a poll, and that the monitor reconnects after losing it
+func TestRunRealtime(t *testing.T) {
+	h := newHarness(t)
+	h.config.SetPollInterval(time.Minute) // Too slow for polling to deliver within the test
+	h.config.Monitor.Realtime = true
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddDM("D1", "U1")
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+	h.waitForRealtime(t)
+
+	h.server.PostMessage("D1", "U1", "are you there?")
+	if got := h.waitForNotification(t); got != "DM from Alice: are you there?" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+
+	h.server.DisconnectRealtime()
+	h.waitForRealtime(t)
+	h.server.PostMessage("D1", "U1", "back again")
+	if got := h.waitForNotification(t); got != "DM from Alice: back again" {
+		t.Errorf("Unexpected notification after reconnecting %q", got)
+	}
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if got := h.server.RequestCount("rtm.connect"); got != 2 {
+		t.Errorf("Expected 2 rtm.connect calls, got %d", got)
+	}
+}
+
+// TestRunRealtimeFallsBackToPolling tests that polling continues when the
+// real-time connection can't be opened
+func TestRunRealtimeFallsBackToPolling(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.Realtime = true
+	h.server.FailMethod("rtm.connect", "not_allowed_token_type")
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddDM("D1", "U1")
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+	h.server.PostMessage("D1", "U1", "polled")
+	if got := h.waitForNotification(t); got != "DM from Alice: polled" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+// TestRunCycleBudget tests that a stuck request can't hold up a cycle past its
+// budget, and that shutdown doesn't wait for it
+func TestRunCycleBudget(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.CycleTimeoutSecs = 1
+	h.server.AddDM("D1", "U1")
+	h.server.Delay("conversations.history", time.Minute)
+
+	stop := h.run(t)
+	start := time.Now()
+	h.waitForCycle(t)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Cycle took %s despite a 1s budget", elapsed)
+	}
+
+	h.waitForCycle(t)
+	start = time.Now()
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown took %s", elapsed)
+	}
+	if _, tracked := h.store.state.LastChecked["D1"]; !tracked {
+		t.Error("Expected progress before the deadline to be saved")
+	}
+}
+
+// TestRunCycleBudgetRetriesUnchecked tests that a conversation cut off by the
+// cycle budget is checked next cycle instead of waiting out its tier interval
+func TestRunCycleBudgetRetriesUnchecked(t *testing.T) {
+	h := newHarness(t)
+	h.config.Monitor.CycleTimeoutSecs = 1
+	h.config.Monitor.TieredPolling = true
+	h.config.Monitor.Workers = 1
+	h.server.FailMethod("client.counts", "unknown_method")
+	h.server.AddDM("D1", "U1")
+	h.server.AddDM("D2", "U2")
+
+	// Both cold, so once checked neither is due again for a long while
+	idleSince := fmt.Sprintf("%d.000000", time.Now().AddDate(0, 0, -90).Unix())
+	h.store.state = &monitor.State{LastChecked: map[string]string{"D1": idleSince, "D2": idleSince}}
+	h.server.Delay("conversations.history", 600*time.Millisecond)
+
+	stop := h.run(t)
+	h.waitForCycle(t) // D1 is checked; D2's fetch is cut off by the budget
+	if got := h.server.RequestCount("conversations.history"); got != 1 {
+		t.Fatalf("Expected 1 history request to finish within the budget, got %d", got)
+	}
+	h.server.Delay("conversations.history", 0)
+	h.waitForCycle(t)
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if got := h.server.RequestCount("conversations.history"); got != 2 {
+		t.Errorf("Expected D2 to be fetched on the next cycle, got %d history requests", got)
+	}
+}
+
+// TestRunStartsWithExpiredSession tests that a session already expired at
+// startup is notified and waited out, and that new tokens which fail to check
+// for reasons other than authentication are tried again
+func TestRunStartsWithExpiredSession(t *testing.T) {
+	h := newHarness(t)
+	credentials := &credentialSource{xoxc: slacktest.DefaultXoxcToken, xoxd: slacktest.DefaultXoxdToken}
+	h.monitor.SetCredentialSource(credentials)
+	h.server.SetTokens("xoxc-renewed", "xoxd-renewed")
+
+	stop := h.run(t)
+	if got := h.waitForNotification(t); !strings.HasPrefix(got, "Slack session expired: ") {
+		t.Fatalf("Expected a session expiry notification, got %q", got)
+	}
+
+	// A Slack outage while checking the new tokens doesn't rule them out
+	h.server.FailMethod("auth.test", "fatal_error")
+	checked := h.server.RequestCount("auth.test")
+	credentials.set("xoxc-renewed", "xoxd-renewed")
+	deadline := time.Now().Add(5 * time.Second)
+	for h.server.RequestCount("auth.test") < checked+2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the new tokens to be checked again")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	h.server.FailMethod("auth.test", "")
+	h.waitForCycle(t)
+
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+// credentialSource is a CredentialSource whose tokens the test can change
+type credentialSource struct {
+	mu         sync.Mutex
+	xoxc, xoxd string
+}
+
+func (s *credentialSource) SlackCredentials() (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.xoxc, s.xoxd, nil
+}
+
+func (s *credentialSource) set(xoxc, xoxd string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.xoxc, s.xoxd = xoxc, xoxd
+}
+
+// TestRunSessionExpiry tests that an expired session sends one notification,
+// pauses polling, and resumes once new tokens are configured
+func TestRunSessionExpiry(t *testing.T) {
+	h := newHarness(t)
+	credentials := &credentialSource{xoxc: slacktest.DefaultXoxcToken, xoxd: slacktest.DefaultXoxdToken}
+	h.monitor.SetCredentialSource(credentials)
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddDM("D1", "U1")
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	// Signing in again elsewhere invalidates the monitor's tokens
+	h.server.SetTokens("xoxc-renewed", "xoxd-renewed")
+	if got := h.waitForNotification(t); !strings.HasPrefix(got, "Slack session expired: ") {
+		t.Fatalf("Expected a session expiry notification, got %q", got)
+	}
+	h.notifier.mu.Lock()
+	if priority := h.notifier.notifications[0].Priority; priority != monitor.PriorityHigh {
+		t.Errorf("Expected high priority, got %s", priority)
+	}
+	h.notifier.mu.Unlock()
+
+	// While paused, nothing is polled and nothing more is sent
+	listed := h.server.RequestCount("conversations.list")
+	time.Sleep(10 * testPollInterval)
+	if got := h.server.RequestCount("conversations.list"); got != listed {
+		t.Errorf("Expected polling to pause, got %d more conversations.list requests", got-listed)
+	}
+	select {
+	case msg := <-h.notifier.sent:
+		t.Errorf("Unexpected notification while paused: %q", msg)
+	default:
+	}
+
+	h.server.PostMessage("D1", "U1", "are you back?")
+	credentials.set("xoxc-renewed", "xoxd-renewed")
+	if got := h.waitForNotification(t); got != "DM from Alice: are you back?" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+// TestRunReloadsConfig tests that a reloaded configuration applies from the next cycle
+func TestRunReloadsConfig(t *testing.T) {
+	h := newHarness(t)
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddUser(slacktest.User{ID: "U2", Name: "bob", RealName: "Bob"})
+	h.server.AddDM("D1", "U1")
+	h.server.AddDM("D2", "U2")
+
+	stop := h.run(t)
+	h.waitForCycle(t)
+
+	reloaded := *h.config
+	reloaded.Rules = []monitor.Rule{
+		{Name: "mute alice", Match: monitor.RuleMatch{Senders: []string{"U1"}}, Action: monitor.RuleActionDrop},
+	}
+	h.monitor.Reload(&reloaded, nil)
+	// A cycle already under way finishes with the old configuration
+	h.waitForCycle(t)
+	h.waitForCycle(t)
+
+	h.server.PostMessage("D1", "U1", "muted now")
+	h.server.PostMessage("D2", "U2", "still here")
+	if got := h.waitForNotification(t); got != "DM from Bob: still here" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+// TestRunOnce tests that a single cycle checks every conversation, saves state and returns
+func TestRunOnce(t *testing.T) {
+	h := newHarness(t)
+	h.server.AddUser(slacktest.User{ID: "U1", Name: "alice", RealName: "Alice"})
+	h.server.AddDM("D1", "U1")
+
+	// The first run only records where each conversation is up to
+	if err := h.monitor.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce returned error: %v", err)
+	}
+
+	h.server.PostMessage("D1", "U1", "since last run")
+	if err := h.monitor.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce returned error: %v", err)
+	}
+	if got := h.notifier.messages; len(got) != 1 || got[0] != "DM from Alice: since last run" {
+		t.Errorf("Expected one notification for the new message, got %v", got)
+	}
+
+	h.server.SetAuthError("invalid_auth")
+	if err := h.monitor.RunOnce(context.Background()); !errors.Is(err, monitor.ErrSessionExpired) {
+		t.Errorf("Expected a session expired error, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// userGroupRefreshInterval controls how often user group membership is reloaded
+const userGroupRefreshInterval = time.Hour
+
+// mentionPattern matches Slack mention tokens such as <@U123>, <@U123|bob>,
+// <!here>, <!channel|channel> and <!subteam^S123|@team>
+var mentionPattern = regexp.MustCompile(`<([@!])([^>|]+)(?:\|[^>]*)?>`)
+
+// Mentions describes who a message mentions
+type Mentions struct {
+	Users      []string // User IDs from <@U...>
+	UserGroups []string // User group IDs from <!subteam^S...>
+	Here       bool     // <!here>
+	Channel    bool     // <!channel>
+	Everyone   bool     // <!everyone>
+}
+
+// parseMentions extracts mention tokens from raw Slack message text
+func parseMentions(text string) Mentions {
+	var mentions Mentions
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		kind, target := match[1], match[2]
+		if kind == "@" {
+			mentions.Users = append(mentions.Users, target)
+			continue
+		}
+
+		switch {
+		case target == "here":
+			mentions.Here = true
+		case target == "channel":
+			mentions.Channel = true
+		case target == "everyone":
+			mentions.Everyone = true
+		case strings.HasPrefix(target, "subteam^"):
+			mentions.UserGroups = append(mentions.UserGroups, strings.TrimPrefix(target, "subteam^"))
+		}
+	}
+	return mentions
+}
+
+// mentionOf returns how a message mentions the given user: "you" for a direct
+// mention, "@here"/"@channel"/"@everyone" for broadcasts, "@<handle>" for one of
+// the user's groups, or "" if the user is not mentioned
+func (mentions Mentions) mentionOf(userID string, userGroups map[string]string) string {
+	for _, id := range mentions.Users {
+		if id == userID {
+			return "you"
+		}
+	}
+	for _, id := range mentions.UserGroups {
+		if handle, ok := userGroups[id]; ok {
+			return "@" + handle
+		}
+	}
+	switch {
+	case mentions.Here:
+		return "@here"
+	case mentions.Channel:
+		return "@channel"
+	case mentions.Everyone:
+		return "@everyone"
+	}
+	return ""
+}
+
+// refreshUserGroups reloads the groups the authenticated user belongs to.
+// Failures are logged and the previous membership is kept; group mentions
+// simply won't match until the next successful refresh.
+func (m *Monitor) refreshUserGroups(ctx context.Context) {
+	groups, err := m.slackClient.GetUserGroups(ctx)
+	m.userGroupsLoaded = time.Now()
+	if err != nil {
+		log.Printf("Failed to load user groups (group mentions will be ignored): %v", err)
+		return
+	}
+
+	memberOf := make(map[string]string)
+	for _, group := range groups {
+		for _, member := range group.Users {
+			if member == m.userID {
+				memberOf[group.ID] = group.Handle
+				break
+			}
+		}
+	}
+	m.userGroups = memberOf
+	log.Printf("Loaded user groups (member of %d of %d)", len(memberOf), len(groups))
+}
+
+// refreshUserGroupsIfStale reloads user groups when channels are monitored and the cache is old
+func (m *Monitor) refreshUserGroupsIfStale(ctx context.Context) {
+	if m.config.Monitor.DMsOnly {
+		return
+	}
+	if time.Since(m.userGroupsLoaded) >= userGroupRefreshInterval {
+		m.refreshUserGroups(ctx)
+	}
+}
@@ -2,29 +2,61 @@ package monitor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 )
 
 // Message represents a message in a Slack conversation
 type Message struct {
-	Timestamp string // Slack message timestamp (unique ID)
-	User      string // User ID who sent the message
-	Text      string // Message text content
-	Type      string // Message type (e.g., "message")
+	Timestamp   string   // Slack message timestamp (unique ID)
+	User        string   // User ID who sent the message
+	Text        string   // Message text content
+	Type        string   // Message type (e.g., "message")
+	ThreadTS    string   // Parent timestamp if the message is in a thread (equals Timestamp for a parent)
+	ReplyCount  int      // Number of thread replies (parents only)
+	LatestReply string   // Timestamp of the newest thread reply (parents only)
+	ReplyUsers  []string // Users who have replied in the thread (parents only)
 }
 
-// Conversation represents a Slack DM conversation
+// Conversation types, matching the values accepted by conversations.list "types"
+const (
+	ConversationTypeIM             = "im"              // Direct message
+	ConversationTypeMPIM           = "mpim"            // Group direct message
+	ConversationTypePrivateChannel = "private_channel" // Private channel
+	ConversationTypePublicChannel  = "public_channel"  // Public channel
+)
+
+// Conversation represents a Slack conversation (DM, group DM or channel)
 type Conversation struct {
-	ID            string // Channel ID (e.g., "D06...")
+	ID            string // Channel ID (e.g., "D06...", "C01...", "G01...")
+	Type          string // Conversation type (one of the ConversationType* constants)
+	Name          string // Channel name (empty for DMs)
 	User          string // Other user's ID in the DM
 	IsUserDeleted bool   // Whether the user has been deleted
+	IsMember      bool   // Whether the authenticated user has joined the channel
+}
+
+// IsDM reports whether the conversation is a one-to-one direct message
+func (c Conversation) IsDM() bool {
+	return c.Type == ConversationTypeIM
+}
+
+// IsChannel reports whether the conversation is a public or private channel
+func (c Conversation) IsChannel() bool {
+	return c.Type == ConversationTypePrivateChannel || c.Type == ConversationTypePublicChannel
 }
 
 // State represents the monitoring state - tracks last checked timestamp per conversation
 type State struct {
-	LastChecked map[string]string // channel_id -> timestamp
+	LastChecked map[string]string            // channel_id -> timestamp
+	Threads     map[string]map[string]string // channel_id -> thread_ts -> last reply timestamp seen
+	Digest      map[string][]Notification    // channel_id -> notifications buffered for the next digest, oldest first
+	LastDigest  time.Time                    // When the digest schedule last came due
+	Held        []Notification               // Notifications held during quiet hours, oldest first
 }
 
 // User represents a Slack user
@@ -34,6 +66,14 @@ type User struct {
 	RealName string
 }
 
+// UserGroup represents a Slack user group (e.g., @engineering)
+type UserGroup struct {
+	ID     string   // User group ID (e.g., "S01...")
+	Handle string   // Mention handle without "@"
+	Name   string   // Display name
+	Users  []string // Member user IDs
+}
+
 // Config represents the application configuration
 type Config struct {
 	Slack struct {
@@ -41,37 +81,190 @@ type Config struct {
 		XoxdToken        string `json:"xoxd_token"`
 		WorkspaceID      string `json:"workspace_id"`
 		PollIntervalSecs int    `json:"poll_interval_seconds"`
+		MaxPages         int    `json:"max_pages"`    // Max cursor pages per paginated API call (0 = client default)
+		APIBaseURL       string `json:"api_base_url"` // Web API root (default https://slack.com/api/)
+		ProxyURL         string `json:"proxy_url"`    // HTTP(S) proxy for Slack requests (default: from environment)
+		TLS              struct {
+			CAFile             string `json:"ca_file"`              // PEM bundle of extra root CAs to trust
+			InsecureSkipVerify bool   `json:"insecure_skip_verify"` // Disable certificate verification (testing only)
+		} `json:"tls"`
 	} `json:"slack"`
 	Notifications struct {
-		NtfyTopic string `json:"ntfy_topic"`
+		NtfyTopic    string               `json:"ntfy_topic"`     // Shorthand for a single ntfy target
+		NtfyServer   string               `json:"ntfy_server"`    // Server for ntfy_topic (default https://ntfy.sh)
+		NtfyToken    string               `json:"ntfy_token"`     // Access token for ntfy_server
+		Targets      []NotificationTarget `json:"targets"`        // Notification backends to fan out to
+		MaxPerMinute int                  `json:"max_per_minute"` // Delivery rate limit; bursts beyond it are coalesced
 	} `json:"notifications"`
 	Monitor struct {
-		DMsOnly bool `json:"dms_only"`
+		DMsOnly           bool                          `json:"dms_only"`
+		ConversationTypes []string                      `json:"conversation_types"`    // Types to monitor when dms_only is false
+		Filters           map[string]ConversationFilter `json:"filters"`               // Per-type include/exclude lists, keyed by conversation type
+		ThreadWatchDays   int                           `json:"thread_watch_days"`     // Days to keep watching a quiet thread (0 = default 7)
+		TieredPolling     bool                          `json:"tiered_polling"`        // Poll quiet conversations less often
+		Tiers             PollTiers                     `json:"tiers"`                 // Tier thresholds and intervals
+		Workers           int                           `json:"workers"`               // Conversations checked at once (0 = default 4)
+		CycleTimeoutSecs  int                           `json:"cycle_timeout_seconds"` // Time budget for one check cycle (0 = default 300)
+		Realtime          bool                          `json:"realtime"`              // Receive messages over a websocket, polling only as a fallback
+		RealtimePollSecs  int                           `json:"realtime_poll_seconds"` // Full check interval while the websocket is up (0 = default 600)
 	} `json:"monitor"`
+	Digest struct {
+		Conversations []string      `json:"conversations"` // Conversations summarized in a digest instead of notified (IDs, #names or DM user IDs)
+		Schedule      *CronSchedule `json:"schedule"`      // When digests are sent, as a cron expression (default @hourly)
+	} `json:"digest"`
+	Rules    []Rule   `json:"rules"`    // Notification rules, first match wins
+	Schedule Schedule `json:"schedule"` // Quiet hours, holidays and VIPs
+
+	pollIntervalOverride time.Duration // Replaces Slack.PollIntervalSecs when set, so tests can poll faster than once a second
+}
+
+// pollInterval returns how long to wait between check cycles
+func (c *Config) pollInterval() time.Duration {
+	if c.pollIntervalOverride > 0 {
+		return c.pollIntervalOverride
+	}
+	return time.Duration(c.Slack.PollIntervalSecs) * time.Second
+}
+
+// NotificationTarget configures one notification backend. Type selects the
+// backend; the remaining JSON fields are backend-specific and kept in Settings
+// for the backend to decode.
+type NotificationTarget struct {
+	Type     string          `json:"type"` // Backend type (e.g., "ntfy", "pushover", "webhook")
+	Name     string          `json:"name"` // Optional label for logs (defaults to the type)
+	Settings json.RawMessage `json:"-"`    // The full target object, decoded by the backend
+}
+
+// UnmarshalJSON decodes the common fields and keeps the whole object for the backend
+func (t *NotificationTarget) UnmarshalJSON(data []byte) error {
+	var common struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &common); err != nil {
+		return err
+	}
+	t.Type = common.Type
+	t.Name = common.Name
+	t.Settings = append(json.RawMessage(nil), data...)
+	return nil
 }
 
-// SlackClient defines the interface for Slack API operations
+// DisplayName returns the target's name, or its type if unnamed
+func (t NotificationTarget) DisplayName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Type
+}
+
+// ConversationFilter selects which conversations of one type are monitored.
+// Entries match a channel ID or name (with or without a leading "#").
+type ConversationFilter struct {
+	Include []string `json:"include"` // Only these conversations (empty = all; for public channels, all joined)
+	Exclude []string `json:"exclude"` // Never these conversations (applied after Include)
+}
+
+// ErrHistoryTruncated is wrapped by GetConversationHistory errors when more
+// messages remain than the client fetches in one call
+var ErrHistoryTruncated = errors.New("history truncated at page cap")
+
+// SlackClient defines the interface for Slack API operations. Every call
+// gives up as soon as its context is cancelled or its deadline passes.
 type SlackClient interface {
 	// TestAuth validates authentication and returns the authenticated user ID
-	TestAuth() (string, error)
+	TestAuth(ctx context.Context) (string, error)
 
 	// GetDMConversations returns all DM conversations
-	GetDMConversations() ([]Conversation, error)
+	GetDMConversations(ctx context.Context) ([]Conversation, error)
+
+	// GetConversations returns all conversations of the given types
+	GetConversations(ctx context.Context, types []string) ([]Conversation, error)
+
+	// GetConversationHistory fetches messages since the given timestamp, newest
+	// first. If there are too many to fetch, it returns the newest ones with an
+	// error wrapping ErrHistoryTruncated.
+	GetConversationHistory(ctx context.Context, channelID, oldestTS string) ([]Message, error)
 
-	// GetConversationHistory fetches messages since the given timestamp
-	GetConversationHistory(channelID, oldestTS string) ([]Message, error)
+	// GetThreadReplies fetches replies in a thread posted after oldestTS, oldest first.
+	// The parent message is not included.
+	GetThreadReplies(ctx context.Context, channelID, threadTS, oldestTS string) ([]Message, error)
+
+	// GetLatestTimestamps returns the timestamp of the newest message in each
+	// conversation the user belongs to, keyed by channel ID, in one request.
+	// The timestamp is empty for conversations without messages.
+	GetLatestTimestamps(ctx context.Context) (map[string]string, error)
+
+	// GetConversationInfo fetches information about a single conversation
+	GetConversationInfo(ctx context.Context, channelID string) (*Conversation, error)
 
 	// GetUserInfo fetches information about a user
-	GetUserInfo(userID string) (*User, error)
+	GetUserInfo(ctx context.Context, userID string) (*User, error)
+
+	// GetUserGroups returns all user groups with their members
+	GetUserGroups(ctx context.Context) ([]UserGroup, error)
 
 	// GetAuthenticatedUserID returns the ID of the authenticated user
 	GetAuthenticatedUserID() string
+
+	// GetTeamID returns the ID of the authenticated workspace
+	GetTeamID() string
+
+	// GetWorkspaceURL returns the web URL of the authenticated workspace
+	GetWorkspaceURL() string
+
+	// SetCredentials replaces the session tokens, e.g. after the old session expired
+	SetCredentials(xoxcToken, xoxdToken string)
+}
+
+// MessageEvent is a message pushed over a real-time connection
+type MessageEvent struct {
+	ChannelID string // Conversation the message was posted in
+	User      string // Sender's user ID (empty for some subtypes, e.g. edits)
+	Timestamp string // Message timestamp
+	ThreadTS  string // Parent timestamp for thread replies
+}
+
+// RealtimeClient is implemented by Slack clients that can push new messages
+// over a websocket instead of waiting for the next poll
+type RealtimeClient interface {
+	// ConnectRealtime opens a real-time connection
+	ConnectRealtime(ctx context.Context) (RealtimeConnection, error)
+}
+
+// RealtimeConnection is an open real-time connection
+type RealtimeConnection interface {
+	// NextEvent blocks until the next message event, returning an error once the connection is lost
+	NextEvent() (MessageEvent, error)
+
+	// Close closes the connection, unblocking NextEvent. It may be called
+	// more than once, from any goroutine.
+	Close() error
+}
+
+// ErrUnknownTarget is wrapped by Notifier errors when a notification is routed
+// only to targets that aren't configured. Retrying won't deliver it.
+var ErrUnknownTarget = errors.New("no notification target named")
+
+// RateLimitStats reports a Slack client's request and throttling counters
+type RateLimitStats struct {
+	Requests    int64         // HTTP requests sent
+	RateLimited int64         // 429 responses received
+	Retries     int64         // Requests retried after a 429 or server error
+	Waited      time.Duration // Total time spent waiting on the limiter and backoff
+}
+
+// rateLimitReporter is implemented by Slack clients that count their requests
+// and throttling, such as slack.Client
+type rateLimitReporter interface {
+	RateLimitStats() RateLimitStats
 }
 
 // Notifier defines the interface for sending notifications
 type Notifier interface {
-	// SendNotification sends a notification message
-	SendNotification(message string) error
+	// SendNotification sends a notification about a Slack message. It may be
+	// called from several goroutines at once.
+	SendNotification(n Notification) error
 }
 
 // StateStore defines the interface for state persistence
@@ -83,44 +276,82 @@ type StateStore interface {
 	Save(state *State) error
 }
 
+// defaultCycleTimeoutSecs bounds a check cycle unless monitor.cycle_timeout_seconds is set
+const defaultCycleTimeoutSecs = 300
+
+// cycleTimeout returns the time budget for one check cycle
+func (c *Config) cycleTimeout() time.Duration {
+	return time.Duration(orDefault(c.Monitor.CycleTimeoutSecs, defaultCycleTimeoutSecs)) * time.Second
+}
+
 // Monitor represents the core monitoring logic
 type Monitor struct {
-	slackClient SlackClient
-	notifier    Notifier
-	stateStore  StateStore
-	config      *Config
-	userCache   map[string]string // userID -> display name cache
+	slackClient  SlackClient
+	notifier     Notifier
+	stateStore   StateStore
+	config       *Config
+	cacheMu      sync.Mutex        // Guards userCache and channelCache, shared by conversation workers
+	userCache    map[string]string // userID -> display name cache
+	channelCache map[string]string // channelID -> channel name cache
+
+	userID           string            // Authenticated user ID (set by Run)
+	userGroups       map[string]string // groupID -> handle, for groups the user belongs to
+	userGroupsLoaded time.Time         // When userGroups was last refreshed
+
+	lastPolled  map[string]time.Time // channelID -> when it was last polled, for tiered polling
+	lastFetched map[string]time.Time // channelID -> when its history was last fetched, for watched threads
+
+	monitored  map[string]Conversation // channelID -> conversation, as of the last full check (for real-time events)
+	unknownDMs map[string]bool         // DMs a real-time event triggered a full check for
+
+	rateLimits RateLimitStats // Slack client counters as of the last cycle, for logging each cycle's share
+
+	heldCheckInterval time.Duration // How often held notifications are checked for release between cycles
+
+	credentials CredentialSource   // Where to look for new tokens once the session expires (optional)
+	reloads     chan pendingConfig // Reloaded configuration waiting to be applied
 }
 
 // NewMonitor creates a new Monitor instance
 func NewMonitor(slackClient SlackClient, notifier Notifier, stateStore StateStore, config *Config) *Monitor {
 	return &Monitor{
-		slackClient: slackClient,
-		notifier:    notifier,
-		stateStore:  stateStore,
-		config:      config,
-		userCache:   make(map[string]string),
+		slackClient:  slackClient,
+		notifier:     notifier,
+		stateStore:   stateStore,
+		config:       config,
+		userCache:    make(map[string]string),
+		channelCache: make(map[string]string),
+		lastPolled:   make(map[string]time.Time),
+		lastFetched:  make(map[string]time.Time),
+		monitored:    make(map[string]Conversation),
+		unknownDMs:   make(map[string]bool),
+		reloads:      make(chan pendingConfig, 1),
+
+		heldCheckInterval: heldCheckInterval,
 	}
 }
 
 // Run starts the monitoring loop
 func (m *Monitor) Run(ctx context.Context) error {
-	// Validate authentication
-	userID, err := m.slackClient.TestAuth()
-	if err != nil {
-		return err
+	state, err := m.start(ctx)
+	if errors.Is(err, ErrSessionExpired) {
+		// Expired before starting, e.g. after a reboot: notify and wait for
+		// new tokens as if it had expired while running
+		if !m.awaitNewSession(ctx, err) {
+			return nil
+		}
+		state, err = m.loadState()
 	}
-	_ = userID // Will be used for message filtering
-
-	// Load state
-	state, err := m.stateStore.Load()
 	if err != nil {
 		return err
 	}
 
-	pollInterval := time.Duration(m.config.Slack.PollIntervalSecs) * time.Second
 	log.Println("Starting monitoring...")
 
+	// New messages arrive over the real-time connection while it is up; full
+	// checks then only catch up on anything missed
+	stream := m.startRealtime(ctx)
+
 	// Use check-then-wait pattern to prevent overlapping cycles
 	for {
 		// Check for cancellation before starting cycle
@@ -130,36 +361,116 @@ func (m *Monitor) Run(ctx context.Context) error {
 		default:
 		}
 
-		// Run check cycle
+		// Run check cycle within its time budget, so a stuck request can't stall monitoring
 		log.Println("Checking for new messages...")
 		cycleStart := time.Now()
-		if err := m.checkAllConversations(ctx, state); err != nil {
+		cycleCtx, cancel := context.WithTimeout(ctx, m.config.cycleTimeout())
+		err := m.checkAllConversations(cycleCtx, state)
+		cancel()
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, ErrSessionExpired):
+			// Nothing can be checked until the user signs in again
+			if !m.awaitNewSession(ctx, err) {
+				return nil
+			}
+			continue
+		case errors.Is(err, context.DeadlineExceeded):
+			log.Printf("Check cycle ran out of its %s budget; unchecked conversations will be checked next cycle", m.config.cycleTimeout())
+		case err != nil:
 			// Log error but continue monitoring
 			log.Printf("Error checking conversations: %v", err)
 		}
 		cycleDuration := time.Since(cycleStart)
 
-		log.Printf("Check cycle completed in %dms, waiting %ds before next cycle", cycleDuration.Milliseconds(), int(pollInterval.Seconds()))
+		interval := m.config.pollInterval()
+		if stream.up {
+			interval = m.config.realtimePollInterval()
+		}
+		log.Printf("Check cycle completed in %dms, waiting %ds before next cycle", cycleDuration.Milliseconds(), int(interval.Seconds()))
+		m.logRateLimits()
 
 		// Wait for configured interval AFTER check completes
-		select {
-		case <-ctx.Done():
+		if !m.waitForNextCycle(ctx, interval, stream, state) {
 			return nil
-		case <-time.After(pollInterval):
-			// Next cycle will start
 		}
 	}
 }
 
-// checkAllConversations checks all DM conversations for new messages
+// RunOnce runs a single check cycle within its time budget and returns, for
+// running from cron or a similar scheduler. Unlike Run, it returns the cycle's error.
+func (m *Monitor) RunOnce(ctx context.Context) error {
+	state, err := m.start(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.config.cycleTimeout())
+	defer cancel()
+	defer m.logRateLimits()
+	return m.checkAllConversations(ctx, state)
+}
+
+// logRateLimits logs the Slack requests made and the throttling met since it
+// was last called, if the client counts them
+func (m *Monitor) logRateLimits() {
+	reporter, ok := m.slackClient.(rateLimitReporter)
+	if !ok {
+		return
+	}
+	stats, last := reporter.RateLimitStats(), m.rateLimits
+	m.rateLimits = stats
+	log.Printf("Slack API: %d request(s), %d rate limited, %d retried, %s spent waiting",
+		stats.Requests-last.Requests, stats.RateLimited-last.RateLimited, stats.Retries-last.Retries, (stats.Waited - last.Waited).Round(time.Millisecond))
+}
+
+// start validates authentication and loads state before checking
+func (m *Monitor) start(ctx context.Context) (*State, error) {
+	userID, err := m.slackClient.TestAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.userID = userID // Used for mention detection
+	return m.loadState()
+}
+
+// loadState loads the saved state, ready for checking
+func (m *Monitor) loadState() (*State, error) {
+	state, err := m.stateStore.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state.Threads == nil {
+		state.Threads = make(map[string]map[string]string)
+	}
+	return state, nil
+}
+
+// checkAllConversations checks all monitored conversations for new messages
 func (m *Monitor) checkAllConversations(ctx context.Context, state *State) error {
-	// Get all DM conversations
-	conversations, err := m.slackClient.GetDMConversations()
+	// Keep user group membership current for @group mentions in channels
+	m.refreshUserGroupsIfStale(ctx)
+
+	// Get all monitored conversations (DMs only, or DMs plus selected channels)
+	conversations, err := m.fetchConversations(ctx)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Checking %d DM conversation(s)", len(conversations))
+	// Channel names from the list are free; cache them for rendering <#C...> references
+	m.cacheMu.Lock()
+	for _, conv := range conversations {
+		if conv.Name != "" && conv.Type != ConversationTypeMPIM {
+			m.channelCache[conv.ID] = conv.Name
+		}
+	}
+	m.cacheMu.Unlock()
+
+	if m.config.Monitor.DMsOnly {
+		log.Printf("Checking %d DM conversation(s)", len(conversations))
+	} else {
+		log.Printf("Checking %d conversation(s)", len(conversations))
+	}
 
 	// Log deleted users with display names
 	var deletedUsers []struct {
@@ -169,7 +480,7 @@ func (m *Monitor) checkAllConversations(ctx context.Context, state *State) error
 	}
 	for _, conv := range conversations {
 		if conv.IsUserDeleted {
-			displayName := m.getUserDisplayName(conv.User)
+			displayName := m.getUserDisplayName(ctx, conv.User)
 			deletedUsers = append(deletedUsers, struct {
 				channelID   string
 				userID      string
@@ -198,22 +509,67 @@ func (m *Monitor) checkAllConversations(ctx context.Context, state *State) error
 
 	log.Printf("Monitoring %d active conversation(s) (skipped %d deleted)", len(activeConversations), len(deletedUsers))
 
-	// Check each active conversation for new messages
+	m.monitored = make(map[string]Conversation, len(activeConversations))
 	for _, conv := range activeConversations {
-		// Check for cancellation before each conversation
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			// Continue processing
+		m.monitored[conv.ID] = conv
+	}
+
+	// One bulk call tells us which conversations have new messages; if it fails,
+	// fall back to fetching history for every conversation
+	latest, err := m.slackClient.GetLatestTimestamps(ctx)
+	if err != nil {
+		log.Printf("Failed to fetch latest timestamps, checking every conversation: %v", err)
+		latest = nil
+	}
+
+	// Check each active conversation that is due for new messages; quiet
+	// conversations are polled less often
+	now := time.Now()
+	tierCounts := make(map[string]int)
+	skipped, unchanged := 0, 0
+	var due []Conversation
+	for _, conv := range activeConversations {
+		tier, isDue := m.isDue(conv, state, now)
+		tierCounts[tier]++
+		if !isDue {
+			skipped++
+			continue
 		}
 
-		if err := m.checkConversation(conv, state); err != nil {
-			// Log error but continue checking other conversations
+		if latest != nil && !m.hasNewMessages(conv.ID, latest, state, now) {
+			m.lastPolled[conv.ID] = now
+			unchanged++
 			continue
 		}
+		due = append(due, conv)
+	}
+	// Only conversations whose check finished count as polled, so any cut off
+	// by the cycle budget are checked again next cycle whatever their tier
+	for _, conv := range m.checkConversations(ctx, due, state) {
+		m.lastPolled[conv.ID] = now
+		m.lastFetched[conv.ID] = now
+	}
+	if err := ctx.Err(); err != nil {
+		// Cancelled or out of time: keep what was checked, and leave the
+		// digest and held notifications for the next cycle
+		if saveErr := m.stateStore.Save(state); saveErr != nil {
+			return saveErr
+		}
+		return err
+	}
+	if m.config.Monitor.TieredPolling {
+		log.Printf("Polled %d conversation(s), skipped %d not yet due (hot %d, warm %d, cold %d)",
+			len(activeConversations)-skipped, skipped, tierCounts[TierHot], tierCounts[TierWarm], tierCounts[TierCold])
+	}
+	if latest != nil {
+		log.Printf("Fetched history for %d conversation(s), %d unchanged", len(activeConversations)-skipped-unchanged, unchanged)
 	}
 
+	// Send the digest when its schedule comes due, and messages held during
+	// quiet hours once they are over
+	m.flushDigest(state)
+	m.flushHeld(state)
+
 	// Save state after each check cycle
 	if err := m.stateStore.Save(state); err != nil {
 		return err
@@ -223,10 +579,14 @@ func (m *Monitor) checkAllConversations(ctx context.Context, state *State) error
 }
 
 // checkConversation checks a single conversation for new messages
-func (m *Monitor) checkConversation(conv Conversation, state *State) error {
+func (m *Monitor) checkConversation(ctx context.Context, conv Conversation, state *State) error {
 	// Get display name for logging
-	displayName := m.getUserDisplayName(conv.User)
-	log.Printf("  → Checking DM with %s (%s)", displayName, conv.ID)
+	if conv.IsDM() {
+		displayName := m.getUserDisplayName(ctx, conv.User)
+		log.Printf("  → Checking DM with %s (%s)", displayName, conv.ID)
+	} else {
+		log.Printf("  → Checking %s (%s)", channelLabel(conv), conv.ID)
+	}
 
 	// Get last checked timestamp for this conversation
 	lastChecked, exists := state.LastChecked[conv.ID]
@@ -236,30 +596,72 @@ func (m *Monitor) checkConversation(conv Conversation, state *State) error {
 		state.LastChecked[conv.ID] = lastChecked
 	}
 
-	// Fetch messages since last check
-	messages, err := m.slackClient.GetConversationHistory(conv.ID, lastChecked)
-	if err != nil {
+	// Fetch messages since last check, reaching back far enough to see the
+	// reply metadata of every watched thread
+	fetchFrom := lastChecked
+	if windowStart := threadWindowStart(state.Threads[conv.ID], lastChecked); windowStart != "" {
+		fetchFrom = windowStart
+	}
+	messages, err := m.slackClient.GetConversationHistory(ctx, conv.ID, fetchFrom)
+	truncated := errors.Is(err, ErrHistoryTruncated)
+	if err != nil && !truncated {
 		return err
 	}
 
+	// Messages older than those fetched are out of reach; if some of them are
+	// new, say so rather than skip them silently
+	if truncated && len(messages) > 0 && compareTimestamps(messages[len(messages)-1].Timestamp, lastChecked) > 0 {
+		log.Printf("Warning: %v", err)
+		if err := m.notifyHistoryGap(ctx, conv, messages, lastChecked, state); err != nil {
+			log.Printf("Failed to send notification for %s: %v", conv.ID, err)
+			return err
+		}
+	}
+
 	// Process messages in reverse order (oldest first)
 	newCount := 0
+	parents := make(map[string]Message)
 	for i := len(messages) - 1; i >= 0; i-- {
 		msg := messages[i]
+		isNew := compareTimestamps(msg.Timestamp, lastChecked) > 0
+		parents[msg.Timestamp] = msg
+		m.watchThread(conv, msg, isNew, state)
+
+		// Older messages were already processed; they're only fetched for thread metadata
+		if !isNew {
+			continue
+		}
 
-		// Skip non-user messages and our own messages
+		// Skip non-user messages and our own messages, moving past them so
+		// change detection doesn't refetch the conversation every cycle
 		if msg.User == "" || msg.Type != "message" || msg.User == m.slackClient.GetAuthenticatedUserID() {
+			state.LastChecked[conv.ID] = msg.Timestamp
 			continue
 		}
 
-		// Get user display name and format notification
-		displayName := m.getUserDisplayName(msg.User)
-		notificationMsg := formatNotification(displayName, msg.Text)
+		// In channels, only messages that mention us are worth a notification
+		// unless a rule says otherwise
+		var mention string
+		if conv.IsChannel() {
+			mention = parseMentions(msg.Text).mentionOf(m.userID, m.userGroups)
+			if mention == "" && len(m.config.Rules) == 0 {
+				state.LastChecked[conv.ID] = msg.Timestamp
+				continue
+			}
+		}
 
-		// Send notification
-		if err := m.notifier.SendNotification(notificationMsg); err != nil {
-			// Log error but continue processing
-			_ = err
+		// Build and send the notification
+		n := m.newNotification(ctx, conv, msg)
+		if mention != "" {
+			n.IsMention, n.Mention = true, mention
+			if mention == "you" {
+				n.Priority = PriorityHigh
+			}
+		}
+		if err := m.deliver(n, !conv.IsChannel() || mention != "", state); err != nil {
+			// Leave LastChecked before this message so the next cycle retries it
+			log.Printf("Failed to send notification for %s: %v", conv.ID, err)
+			return err
 		}
 
 		newCount++
@@ -269,11 +671,93 @@ func (m *Monitor) checkConversation(conv Conversation, state *State) error {
 	}
 
 	// Note: If newCount == 0, we intentionally do NOT update state.LastChecked
-	// Preserving the actual timestamp allows tiered monitoring to work correctly
+	// Preserving the actual timestamp lets tiered polling see how long the
+	// conversation has been quiet (see State.LastActivity)
+
+	// Check watched threads for new replies
+	m.checkThreads(ctx, conv, parents, state)
 
 	return nil
 }
 
+// notifyHistoryGap tells the user that new messages in conv older than those
+// fetched were cut off by the history page cap and won't be notified
+func (m *Monitor) notifyHistoryGap(ctx context.Context, conv Conversation, messages []Message, lastChecked string, state *State) error {
+	oldest := messages[len(messages)-1]
+	name := channelLabel(conv)
+	if conv.IsDM() {
+		name = "DM with " + m.getUserDisplayName(ctx, conv.User)
+	}
+	n := Notification{
+		ID:               "gap/" + conv.ID + "/" + oldest.Timestamp,
+		Subject:          "Messages not shown in " + name,
+		Sender:           "Slack Monitor",
+		ConversationID:   conv.ID,
+		ConversationType: conv.Type,
+		TeamID:           m.slackClient.GetTeamID(),
+		Text: fmt.Sprintf("More messages arrived since %s than the monitor can fetch at once. Only the newest %d were checked; open Slack to read the rest, or raise slack.max_pages.",
+			parseTimestamp(lastChecked).Local().Format("Jan 2 15:04"), len(messages)),
+		Timestamp: previousTimestamp(oldest.Timestamp),
+		Priority:  PriorityDefault,
+		Permalink: permalink(m.slackClient.GetWorkspaceURL(), conv.ID, oldest.Timestamp, ""),
+	}
+	if !conv.IsDM() {
+		n.Channel = name
+	}
+	return m.send(n, state)
+}
+
+// hasNewMessages reports whether a conversation may have messages we haven't
+// processed, given the newest timestamps reported by GetLatestTimestamps.
+// Conversations seen for the first time or missing from the report are always
+// checked, and those with watched threads when the threads are due.
+func (m *Monitor) hasNewMessages(channelID string, latest map[string]string, state *State, now time.Time) bool {
+	lastChecked, tracked := state.LastChecked[channelID]
+	newest, reported := latest[channelID]
+	if !tracked || !reported || compareTimestamps(newest, lastChecked) > 0 {
+		return true
+	}
+	return m.threadsDue(channelID, state, now)
+}
+
+// deliver applies the first rule matching n and sends, holds for the digest, or
+// drops it accordingly. Without a matching rule, n is sent if notify is set,
+// or added to the digest if its conversation is in digest mode.
+func (m *Monitor) deliver(n Notification, notify bool, state *State) error {
+	i := MatchRule(m.config.Rules, n)
+	if i < 0 {
+		switch m.config.DefaultAction(n, notify) {
+		case RuleActionDrop:
+			return nil
+		case RuleActionDigest:
+			m.addToDigest(n, state)
+			return nil
+		}
+		return m.send(n, state)
+	}
+
+	rule := m.config.Rules[i]
+	n = rule.Apply(n)
+	switch rule.ActionName() {
+	case RuleActionDrop:
+		log.Printf("Dropped message %s by rule %s", n.DeliveryID(), rule.DisplayName(i))
+		return nil
+	case RuleActionDigest:
+		m.addToDigest(n, state)
+		return nil
+	}
+	return m.send(n, state)
+}
+
+// send sends n, or holds it for later if the schedule says it's quiet time
+func (m *Monitor) send(n Notification, state *State) error {
+	if m.config.Schedule.Holds(n, time.Now()) {
+		state.Held = append(state.Held, n)
+		return nil
+	}
+	return m.notifier.SendNotification(n)
+}
+
 // formatTimestamp formats a time.Time as a Slack timestamp
 func formatTimestamp(t time.Time) string {
 	return formatFloat(float64(t.Unix()))
@@ -284,31 +768,25 @@ func formatFloat(f float64) string {
 	return fmt.Sprintf("%.6f", f)
 }
 
-// formatNotification formats a message for notification
-func formatNotification(userName, messageText string) string {
-	const maxLength = 500
-	if len(messageText) > maxLength {
-		messageText = messageText[:maxLength-3] + "..."
-	}
-	return fmt.Sprintf("DM from %s: %s", userName, messageText)
-}
-
 // getUserDisplayName gets a user's display name (from cache or API)
-func (m *Monitor) getUserDisplayName(userID string) string {
+func (m *Monitor) getUserDisplayName(ctx context.Context, userID string) string {
 	// Check cache first
-	if displayName, exists := m.userCache[userID]; exists {
+	m.cacheMu.Lock()
+	displayName, exists := m.userCache[userID]
+	m.cacheMu.Unlock()
+	if exists {
 		return displayName
 	}
 
 	// Fetch from API
-	user, err := m.slackClient.GetUserInfo(userID)
+	user, err := m.slackClient.GetUserInfo(ctx, userID)
 	if err != nil {
 		// Fallback to user ID on error
 		return userID
 	}
 
 	// Determine display name
-	displayName := user.RealName
+	displayName = user.RealName
 	if displayName == "" {
 		displayName = user.Name
 	}
@@ -317,6 +795,8 @@ func (m *Monitor) getUserDisplayName(userID string) string {
 	}
 
 	// Cache for future use
+	m.cacheMu.Lock()
 	m.userCache[userID] = displayName
+	m.cacheMu.Unlock()
 	return displayName
 }
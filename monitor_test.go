@@ -1,8 +1,16 @@
 package monitor
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 // TestFormatNotification tests message formatting for notifications
@@ -35,9 +43,729 @@ func TestFormatNotification(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := formatNotification(tt.userName, tt.message)
+		result := Notification{Sender: tt.userName, Text: tt.message}.String()
 		if result != tt.expected {
-			t.Errorf("formatNotification(%q, %q) = %q, want %q", tt.userName, tt.message, result, tt.expected)
+			t.Errorf("Notification{%q, %q}.String() = %q, want %q", tt.userName, tt.message, result, tt.expected)
 		}
 	}
 }
+
+// TestFormatChannelNotification tests the string forms of channel, mention and thread notifications
+func TestFormatChannelNotification(t *testing.T) {
+	tests := []struct {
+		n        Notification
+		expected string
+	}{
+		{
+			n:        Notification{Sender: "Alice", Channel: "#general", Text: "deploy is done"},
+			expected: "Alice in #general: deploy is done",
+		},
+		{
+			n:        Notification{Sender: "Alice", Channel: "#deploys", Mention: "you", IsMention: true, Text: "v1.2 is live"},
+			expected: "Alice mentioned you in #deploys: v1.2 is live",
+		},
+		{
+			n:        Notification{Sender: "Alice", Timestamp: "2.000000", ThreadTS: "1.000000", Text: "sure"},
+			expected: "Thread reply from Alice: sure",
+		},
+		{
+			n:        Notification{Sender: "Bob", Channel: "#eng", Timestamp: "2.000000", ThreadTS: "1.000000", Text: "lgtm"},
+			expected: "Bob replied in thread in #eng: lgtm",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.n.String(); got != tt.expected {
+			t.Errorf("String() = %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+// TestPermalink tests web links to messages and thread replies
+func TestPermalink(t *testing.T) {
+	if got := permalink("", "C1", "1700000000.000100", ""); got != "" {
+		t.Errorf("Expected no permalink without a workspace URL, got %q", got)
+	}
+	if got, want := permalink("https://acme.slack.com/", "C1", "1700000000.000100", ""), "https://acme.slack.com/archives/C1/p1700000000000100"; got != want {
+		t.Errorf("permalink() = %q, want %q", got, want)
+	}
+	if got, want := permalink("https://acme.slack.com/", "C1", "1700000001.000200", "1700000000.000100"), "https://acme.slack.com/archives/C1/p1700000001000200?cid=C1&thread_ts=1700000000.000100"; got != want {
+		t.Errorf("permalink() = %q, want %q", got, want)
+	}
+}
+
+// TestChannelLabel tests labels for channels and group DMs
+func TestChannelLabel(t *testing.T) {
+	tests := []struct {
+		conv     Conversation
+		expected string
+	}{
+		{Conversation{ID: "C1", Type: ConversationTypePublicChannel, Name: "general"}, "#general"},
+		{Conversation{ID: "G1", Type: ConversationTypePrivateChannel, Name: "secret"}, "#secret"},
+		{Conversation{ID: "G2", Type: ConversationTypeMPIM, Name: "mpdm-alice--bob--john-doe-1"}, "group DM (alice, bob, john-doe)"},
+		{Conversation{ID: "C2", Type: ConversationTypePublicChannel}, "C2"},
+	}
+
+	for _, tt := range tests {
+		if got := channelLabel(tt.conv); got != tt.expected {
+			t.Errorf("channelLabel(%+v) = %q, want %q", tt.conv, got, tt.expected)
+		}
+	}
+}
+
+// TestFilterConversations tests per-type include/exclude lists
+func TestFilterConversations(t *testing.T) {
+	conversations := []Conversation{
+		{ID: "D1", Type: ConversationTypeIM, User: "U1", IsMember: true},
+		{ID: "D2", Type: ConversationTypeIM, User: "U2", IsMember: true},
+		{ID: "G1", Type: ConversationTypeMPIM, Name: "mpdm-a--b-1", IsMember: true},
+		{ID: "G2", Type: ConversationTypePrivateChannel, Name: "team-private", IsMember: true},
+		{ID: "G3", Type: ConversationTypePrivateChannel, Name: "noisy-alerts", IsMember: true},
+		{ID: "C1", Type: ConversationTypePublicChannel, Name: "general", IsMember: true},
+		{ID: "C2", Type: ConversationTypePublicChannel, Name: "random", IsMember: false},
+		{ID: "C3", Type: ConversationTypePublicChannel, Name: "announcements", IsMember: false},
+	}
+
+	ids := func(convs []Conversation) string {
+		var out []string
+		for _, c := range convs {
+			out = append(out, c.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// No filters: everything except public channels the user hasn't joined
+	if got := ids(filterConversations(conversations, nil)); got != "D1,D2,G1,G2,G3,C1" {
+		t.Errorf("Unfiltered = %s", got)
+	}
+
+	filters := map[string]ConversationFilter{
+		ConversationTypeIM:             {Exclude: []string{"U2"}},
+		ConversationTypePrivateChannel: {Exclude: []string{"#noisy-alerts"}},
+		ConversationTypePublicChannel:  {Include: []string{"announcements", "C1"}, Exclude: []string{"general"}},
+	}
+	if got := ids(filterConversations(conversations, filters)); got != "D1,G1,G2,C3" {
+		t.Errorf("Filtered = %s", got)
+	}
+}
+
+// TestParseMentions tests extraction of user, broadcast and group mentions
+func TestParseMentions(t *testing.T) {
+	text := "<@U1> and <@U2|bob>, <!here|here> <!subteam^S9|@eng> <!channel> <#C1|general> <https://x.com|link>"
+	mentions := parseMentions(text)
+
+	if strings.Join(mentions.Users, ",") != "U1,U2" {
+		t.Errorf("Users = %v, want [U1 U2]", mentions.Users)
+	}
+	if strings.Join(mentions.UserGroups, ",") != "S9" {
+		t.Errorf("UserGroups = %v, want [S9]", mentions.UserGroups)
+	}
+	if !mentions.Here || !mentions.Channel || mentions.Everyone {
+		t.Errorf("Broadcasts = here:%v channel:%v everyone:%v", mentions.Here, mentions.Channel, mentions.Everyone)
+	}
+}
+
+// TestMentionOf tests mention precedence: direct, then group, then broadcast
+func TestMentionOf(t *testing.T) {
+	groups := map[string]string{"S1": "oncall"}
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"hello", ""},
+		{"<@U2> hi", ""},
+		{"<!here> <@ME> look", "you"},
+		{"<!channel> <!subteam^S1|@oncall>", "@oncall"},
+		{"<!subteam^S2|@other>", ""},
+		{"<!everyone> all hands", "@everyone"},
+		{"<!channel>", "@channel"},
+	}
+
+	for _, tt := range tests {
+		if got := parseMentions(tt.text).mentionOf("ME", groups); got != tt.expected {
+			t.Errorf("mentionOf(%q) = %q, want %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+// TestTimestampHelpers tests Slack timestamp comparison and arithmetic
+func TestTimestampHelpers(t *testing.T) {
+	if compareTimestamps("1700000000.000010", "1700000000.000009") != 1 {
+		t.Error("Expected later microseconds to compare greater")
+	}
+	if compareTimestamps("1700000000.5", "1700000000.500000") != 0 {
+		t.Error("Expected short fraction to be padded")
+	}
+	if compareTimestamps("1699999999.999999", "1700000000.000000") != -1 {
+		t.Error("Expected earlier second to compare less")
+	}
+	if got := previousTimestamp("1700000000.000000"); got != "1699999999.999999" {
+		t.Errorf("previousTimestamp() = %s", got)
+	}
+	if got := previousTimestamp("1700000000.000100"); got != "1700000000.000099" {
+		t.Errorf("previousTimestamp() = %s", got)
+	}
+}
+
+// TestThreadWindowStart tests how far back history is fetched for watched threads
+func TestThreadWindowStart(t *testing.T) {
+	threads := map[string]string{
+		"1700000100.000000": "1700000200.000000",
+		"1700000050.000001": "1700000050.000001",
+	}
+	if got := threadWindowStart(threads, "1700000300.000000"); got != "1700000050.000000" {
+		t.Errorf("threadWindowStart() = %s, want just before oldest thread", got)
+	}
+	if got := threadWindowStart(threads, "1700000000.000000"); got != "" {
+		t.Errorf("threadWindowStart() = %s, want empty when threads are newer than last check", got)
+	}
+	if got := threadWindowStart(nil, "1700000000.000000"); got != "" {
+		t.Errorf("threadWindowStart() = %s, want empty with no threads", got)
+	}
+}
+
+// stubSlackClient serves user and channel lookups for formatter tests, and
+// records credential changes
+type stubSlackClient struct {
+	SlackClient
+	users       map[string]*User
+	channels    map[string]*Conversation
+	credentials []string
+	userID      string // Returned by TestAuth
+	authErr     error  // Returned by TestAuth instead, if set
+}
+
+func (c *stubSlackClient) SetCredentials(xoxcToken, xoxdToken string) {
+	c.credentials = append(c.credentials, xoxcToken+"/"+xoxdToken)
+}
+
+func (c *stubSlackClient) TestAuth(ctx context.Context) (string, error) {
+	return c.userID, c.authErr
+}
+
+func (c *stubSlackClient) GetUserInfo(ctx context.Context, userID string) (*User, error) {
+	if user, ok := c.users[userID]; ok {
+		return user, nil
+	}
+	return nil, fmt.Errorf("user_not_found")
+}
+
+func (c *stubSlackClient) GetConversationInfo(ctx context.Context, channelID string) (*Conversation, error) {
+	if conv, ok := c.channels[channelID]; ok {
+		return conv, nil
+	}
+	return nil, fmt.Errorf("channel_not_found")
+}
+
+// TestRenderMrkdwn tests conversion of Slack mrkdwn to readable notification text
+func TestRenderMrkdwn(t *testing.T) {
+	client := &stubSlackClient{
+		users: map[string]*User{
+			"U1": {ID: "U1", Name: "alice", RealName: "Alice Smith"},
+		},
+		channels: map[string]*Conversation{
+			"C2": {ID: "C2", Name: "random"},
+		},
+	}
+	m := NewMonitor(client, nil, nil, &Config{})
+	m.userGroups = map[string]string{"S1": "oncall"}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"hey <@U1>!", "hey @Alice Smith!"},
+		{"<@U9|bob> unknown user keeps label", "@bob unknown user keeps label"},
+		{"<@U9> unknown user", "@U9 unknown user"},
+		{"see <#C1|general> and <#C2>", "see #general and #random"},
+		{"<!here> <!channel> <!everyone>", "@here @channel @everyone"},
+		{"<!subteam^S1> <!subteam^S2|@design> <!subteam^S3>", "@oncall @design @group"},
+		{"docs: <https://example.com/a?b=1&amp;c=2|the guide>", "docs: the guide (https://example.com/a?b=1&c=2)"},
+		{"<https://example.com>", "https://example.com"},
+		{"<mailto:bob@example.com|bob@example.com>", "bob@example.com"},
+		{"a &lt; b &amp;&amp; c &gt; d", "a < b && c > d"},
+		{"ship it :rocket: :+1::skin-tone-3: :not_an_emoji:", "ship it 🚀 👍 :not_an_emoji:"},
+		{"at <!date^1392734382^{date}|Feb 18, 2014>", "at Feb 18, 2014"},
+		{"time 10:30:45", "time 10:30:45"},
+	}
+
+	for _, tt := range tests {
+		if got := m.renderMrkdwn(context.Background(), tt.input); got != tt.expected {
+			t.Errorf("renderMrkdwn(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestTruncateMessageRunes tests that truncation never splits multi-byte characters
+func TestTruncateMessageRunes(t *testing.T) {
+	message := strings.Repeat("é", 300) + strings.Repeat("🚀", 300)
+	got := truncateMessage(message)
+	if !utf8.ValidString(got) {
+		t.Fatal("Truncated message is not valid UTF-8")
+	}
+	if n := utf8.RuneCountInString(got); n != 500 {
+		t.Errorf("Expected 500 runes, got %d", n)
+	}
+	if !strings.HasSuffix(got, "🚀...") {
+		t.Errorf("Expected rocket before ellipsis, got suffix %q", got[len(got)-10:])
+	}
+}
+
+// TestMatchRule tests rule conditions and first-match ordering
+func TestMatchRule(t *testing.T) {
+	yes, no := true, false
+	var pattern Pattern
+	if err := pattern.UnmarshalText([]byte(`(?i)\bsev[12]\b`)); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	hours, err := ParseTimeRange("22:00-07:00")
+	if err != nil {
+		t.Fatalf("ParseTimeRange: %v", err)
+	}
+
+	rules := []Rule{
+		{Name: "boss", Match: RuleMatch{Senders: []string{"@Carol"}}, Priority: "urgent"},
+		{Name: "incidents", Match: RuleMatch{Conversations: []string{"#incidents"}, Regex: &pattern}},
+		{Name: "bots", Match: RuleMatch{Keywords: []string{"[BOT]"}, Mention: &no}, Action: RuleActionDrop},
+		{Name: "night threads", Match: RuleMatch{Thread: &yes, Hours: &hours, Days: []string{"sat", "Sunday"}}, Action: RuleActionDigest},
+		{Name: "channels", Match: RuleMatch{ConversationTypes: []string{ConversationTypePublicChannel}}},
+	}
+
+	// Saturday 2023-11-18 23:30 and Monday 2023-11-20 12:00, local time
+	saturdayNight := fmt.Sprintf("%d.000000", time.Date(2023, 11, 18, 23, 30, 0, 0, time.Local).Unix())
+	mondayNoon := fmt.Sprintf("%d.000000", time.Date(2023, 11, 20, 12, 0, 0, 0, time.Local).Unix())
+
+	tests := []struct {
+		name string
+		n    Notification
+		want int
+	}{
+		{"sender by name", Notification{Sender: "Carol", SenderID: "U9", ConversationType: ConversationTypeIM, Timestamp: mondayNoon}, 0},
+		{"channel and regex", Notification{Sender: "Bob", ConversationID: "C1", Channel: "#incidents", ConversationType: ConversationTypePrivateChannel, Text: "SEV1 declared", Timestamp: mondayNoon}, 1},
+		{"regex miss", Notification{Sender: "Bob", ConversationID: "C1", Channel: "#incidents", ConversationType: ConversationTypePrivateChannel, Text: "sev3", Timestamp: mondayNoon}, -1},
+		{"keyword without mention", Notification{Sender: "CI", Text: "[bot] build passed", Timestamp: mondayNoon}, 2},
+		{"keyword with mention", Notification{Sender: "CI", Text: "[bot] build failed", IsMention: true, Timestamp: mondayNoon}, -1},
+		{"thread at night on weekend", Notification{Sender: "Bob", Timestamp: saturdayNight, ThreadTS: "1.000000"}, 3},
+		{"thread on weekday", Notification{Sender: "Bob", Timestamp: mondayNoon, ThreadTS: "1.000000"}, -1},
+		{"conversation type", Notification{Sender: "Bob", Channel: "#general", ConversationType: ConversationTypePublicChannel, Timestamp: mondayNoon}, 4},
+	}
+	for _, tt := range tests {
+		if got := MatchRule(rules, tt.n); got != tt.want {
+			t.Errorf("%s: MatchRule = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	n := rules[0].Apply(Notification{Priority: PriorityDefault, Tags: []string{"a"}})
+	if n.Priority != PriorityUrgent {
+		t.Errorf("Apply priority = %s, want urgent", n.Priority)
+	}
+	n = Rule{Targets: []string{"phone"}, Tags: []string{"b"}}.Apply(n)
+	if strings.Join(n.Targets, ",") != "phone" || strings.Join(n.Tags, ",") != "a,b" {
+		t.Errorf("Apply targets = %v, tags = %v", n.Targets, n.Tags)
+	}
+}
+
+// TestRuleValidate tests rejection of unknown actions, priorities, types and days
+func TestRuleValidate(t *testing.T) {
+	valid := Rule{Action: RuleActionDigest, Priority: "low", Match: RuleMatch{ConversationTypes: []string{ConversationTypeMPIM}, Days: []string{"mon"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid rule, got: %v", err)
+	}
+
+	invalid := []Rule{
+		{Action: "mute"},
+		{Priority: "loud"},
+		{Match: RuleMatch{ConversationTypes: []string{"channels"}}},
+		{Match: RuleMatch{Days: []string{"someday"}}},
+	}
+	for _, rule := range invalid {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", rule)
+		}
+	}
+
+	var r TimeRange
+	if err := r.UnmarshalText([]byte("9am-5pm")); err == nil {
+		t.Error("Expected error for malformed time range")
+	}
+}
+
+// TestScheduleIsQuiet tests weekly windows, midnight wrap, holidays and time zones
+func TestScheduleIsQuiet(t *testing.T) {
+	var schedule Schedule
+	err := json.Unmarshal([]byte(`{
+		"timezone": "America/New_York",
+		"quiet_hours": [
+			{"days": ["mon", "tue", "wed", "thu", "fri"], "hours": "22:00-07:00"},
+			{"days": ["sat", "sun"]}
+		],
+		"holidays": ["2023-11-23"],
+		"vips": ["U1", "Carol"]
+	}`), &schedule)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := schedule.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	ny := schedule.Timezone.Location
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"weekday afternoon", time.Date(2023, 11, 21, 15, 0, 0, 0, ny), false},
+		{"weekday night", time.Date(2023, 11, 21, 23, 0, 0, 0, ny), true},
+		{"weekday early morning", time.Date(2023, 11, 22, 6, 59, 0, 0, ny), true},
+		{"window end is exclusive", time.Date(2023, 11, 22, 7, 0, 0, 0, ny), false},
+		{"monday before 07:00 follows a weekend day", time.Date(2023, 11, 20, 6, 0, 0, 0, ny), false},
+		{"saturday morning after friday night", time.Date(2023, 11, 25, 6, 0, 0, 0, ny), true},
+		{"holiday", time.Date(2023, 11, 23, 12, 0, 0, 0, ny), true},
+		{"converted from UTC", time.Date(2023, 11, 21, 20, 0, 0, 0, time.UTC), false},
+		{"converted from UTC at night", time.Date(2023, 11, 22, 4, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		if got := schedule.IsQuiet(tt.at); got != tt.want {
+			t.Errorf("%s: IsQuiet(%s) = %v, want %v", tt.name, tt.at, got, tt.want)
+		}
+	}
+
+	night := time.Date(2023, 11, 21, 23, 0, 0, 0, ny)
+	if !schedule.Holds(Notification{SenderID: "U2", Priority: PriorityHigh}, night) {
+		t.Error("Expected a high-priority message from a non-VIP to be held")
+	}
+	if schedule.Holds(Notification{SenderID: "U9", Sender: "carol"}, night) {
+		t.Error("Expected a VIP message to break through")
+	}
+	if schedule.Holds(Notification{SenderID: "U2", Priority: PriorityUrgent}, night) {
+		t.Error("Expected an urgent message to break through")
+	}
+
+	if err := (Schedule{Holidays: []string{"25/12/2023"}}).Validate(); err == nil {
+		t.Error("Expected error for malformed holiday")
+	}
+}
+
+// sliceNotifier records notifications in a slice
+type sliceNotifier struct {
+	sent []Notification
+}
+
+func (s *sliceNotifier) SendNotification(n Notification) error {
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+// TestQuietHoursHoldAndFlush tests that held messages are summarized once quiet hours end
+func TestQuietHoursHoldAndFlush(t *testing.T) {
+	notifier := &sliceNotifier{}
+	config := &Config{}
+	config.Schedule.QuietHours = []QuietWindow{{}} // Always quiet
+	config.Schedule.VIPs = []string{"U1"}
+	m := NewMonitor(nil, notifier, nil, config)
+	state := &State{}
+
+	for _, n := range []Notification{
+		{SenderID: "U2", Sender: "Bob", ConversationID: "D2", Timestamp: "1.000000", Text: "one"},
+		{SenderID: "U1", Sender: "Alice", ConversationID: "D1", Timestamp: "2.000000", Text: "from the boss"},
+		{SenderID: "U2", Sender: "Bob", ConversationID: "D2", Timestamp: "3.000000", Text: "two"},
+		{SenderID: "U3", Sender: "Carol", ConversationID: "D3", Timestamp: "4.000000", Text: "by email", Targets: []string{"email"}},
+	} {
+		if err := m.send(n, state); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Text != "from the boss" || len(state.Held) != 3 {
+		t.Fatalf("Expected only the VIP message sent and 3 held, got sent %v, held %v", notifier.sent, state.Held)
+	}
+
+	m.flushHeld(state)
+	if len(notifier.sent) != 1 {
+		t.Fatal("Expected held messages to wait while still quiet")
+	}
+
+	// Held messages keep the targets rules routed them to
+	config.Schedule.QuietHours = nil
+	m.flushHeld(state)
+	if len(notifier.sent) != 3 || notifier.sent[1].Title() != "2 new messages from Bob" || notifier.sent[1].ID != "held/D2/3.000000" || len(notifier.sent[1].Targets) != 0 {
+		t.Errorf("Expected one summary of Bob's held messages to every target, got %+v", notifier.sent)
+	}
+	if len(notifier.sent) == 3 && (notifier.sent[2].Text != "by email" || strings.Join(notifier.sent[2].Targets, ",") != "email") {
+		t.Errorf("Expected Carol's held message sent by email only, got %+v", notifier.sent[2])
+	}
+	if len(state.Held) != 0 {
+		t.Errorf("Expected held messages cleared, got %v", state.Held)
+	}
+}
+
+// savingStore signals each save
+type savingStore struct {
+	saves chan *State
+}
+
+func (s *savingStore) Load() (*State, error) { return &State{}, nil }
+
+func (s *savingStore) Save(state *State) error {
+	s.saves <- state
+	return nil
+}
+
+// TestReleaseHeldBetweenCycles tests that held messages are sent once quiet
+// hours are over without waiting for the next check cycle
+func TestReleaseHeldBetweenCycles(t *testing.T) {
+	notifier := &sliceNotifier{}
+	store := &savingStore{saves: make(chan *State, 1)}
+	m := NewMonitor(nil, notifier, store, &Config{})
+	m.heldCheckInterval = 10 * time.Millisecond
+	state := &State{Held: []Notification{{Sender: "Bob", ConversationID: "D2", Timestamp: "1.000000", Text: "one"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() { done <- m.waitForNextCycle(ctx, time.Hour, &realtimeStream{}, state) }()
+	select {
+	case <-store.saves:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for held messages to be released")
+	}
+	cancel()
+	<-done
+
+	if len(notifier.sent) != 1 || notifier.sent[0].Text != "one" || len(state.Held) != 0 {
+		t.Errorf("Expected the held message sent before the next cycle, got sent %v, held %v", notifier.sent, state.Held)
+	}
+}
+
+// TestCronSchedule tests cron parsing and next-run calculation
+func TestCronSchedule(t *testing.T) {
+	from := time.Date(2023, 11, 17, 10, 20, 30, 0, time.UTC) // Friday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"@hourly", time.Date(2023, 11, 17, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2023, 11, 17, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2023, 11, 17, 13, 0, 0, 0, time.UTC)},
+		{"30 8,18 * * 1-5", time.Date(2023, 11, 17, 18, 30, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2023, 11, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2023, 11, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1", time.Date(2023, 11, 20, 0, 0, 0, 0, time.UTC)}, // Day of month or weekday
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.expr, from, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@sometimes"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+// TestDigest tests buffering digest conversations and flushing on schedule
+func TestDigest(t *testing.T) {
+	notifier := &sliceNotifier{}
+	config := &Config{}
+	config.Digest.Conversations = []string{"#random", "U1"}
+	config.Rules = []Rule{{Match: RuleMatch{Senders: []string{"U4"}}, Action: RuleActionDigest, Targets: []string{"email"}}}
+	m := NewMonitor(nil, notifier, nil, config)
+	state := &State{}
+
+	messages := []Notification{
+		{SenderID: "U2", Sender: "Bob", ConversationID: "C1", ConversationType: ConversationTypePublicChannel, Channel: "#random", Timestamp: "2.000000", Text: "lunch?"},
+		{SenderID: "U1", Sender: "Alice", ConversationID: "D1", ConversationType: ConversationTypeIM, Timestamp: "1.000000", Text: "no rush,\nwhenever " + strings.Repeat("x", 200)},
+		{SenderID: "U3", Sender: "Carol", ConversationID: "D3", ConversationType: ConversationTypeIM, Timestamp: "3.000000", Text: "not in digest mode"},
+		{SenderID: "U4", Sender: "Dan", ConversationID: "D4", ConversationType: ConversationTypeIM, Timestamp: "4.000000", Text: "weekly report"},
+	}
+	for _, n := range messages {
+		if err := m.deliver(n, true, state); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+	}
+	if len(notifier.sent) != 1 || len(state.Digest) != 3 {
+		t.Fatalf("Expected 1 sent and 3 conversations buffered, got sent %v, digest %v", notifier.sent, state.Digest)
+	}
+
+	// The first cycle only starts the schedule; the next hourly run sends the digest
+	m.flushDigest(state)
+	if len(notifier.sent) != 1 || state.LastDigest.IsZero() {
+		t.Fatal("Expected the first flush to only record the schedule start")
+	}
+	state.LastDigest = state.LastDigest.Add(-time.Hour)
+	m.flushDigest(state)
+	if len(notifier.sent) != 3 || state.Digest != nil {
+		t.Fatalf("Expected two digests sent and the buffer cleared, got sent %v, digest %v", notifier.sent, state.Digest)
+	}
+
+	digest := notifier.sent[1]
+	want := "DM with Alice (1)\n• no rush, whenever " + strings.Repeat("x", 81) + "…\n#random (1)\n• Bob: lunch?"
+	if digest.Text != want {
+		t.Errorf("Digest text =\n%s\nwant\n%s", digest.Text, want)
+	}
+	if digest.Title() != "2 new messages from Alice, Bob" || digest.ID != "digest/C1/2.000000" || len(digest.Targets) != 0 {
+		t.Errorf("Unexpected digest title %q, ID %q or targets %v", digest.Title(), digest.ID, digest.Targets)
+	}
+
+	// A rule's digest goes only to the rule's targets
+	routed := notifier.sent[2]
+	if routed.Text != "DM with Dan (1)\n• weekly report" || strings.Join(routed.Targets, ",") != "email" {
+		t.Errorf("Unexpected routed digest %q to %v", routed.Text, routed.Targets)
+	}
+}
+
+// TestPollTiers tests tier classification, intervals and due checks
+func TestPollTiers(t *testing.T) {
+	config := &Config{}
+	config.Slack.PollIntervalSecs = 60
+	config.Monitor.TieredPolling = true
+	config.Monitor.Tiers.WarmIntervalSecs = 600
+	now := time.Date(2023, 11, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		idle time.Duration
+		want string
+	}{
+		{time.Hour, TierHot},
+		{3 * 24 * time.Hour, TierWarm},
+		{90 * 24 * time.Hour, TierCold},
+	}
+	for _, tt := range tests {
+		if got := config.Tier(now.Add(-tt.idle), now); got != tt.want {
+			t.Errorf("Tier(idle %s) = %s, want %s", tt.idle, got, tt.want)
+		}
+	}
+	if got := config.TierInterval(TierWarm); got != 10*time.Minute {
+		t.Errorf("Warm interval = %s, want 10m", got)
+	}
+	if got := config.TierInterval(TierCold); got != 30*time.Minute {
+		t.Errorf("Cold interval = %s, want default 30m", got)
+	}
+
+	// A watched thread's latest reply counts as activity
+	state := &State{
+		LastChecked: map[string]string{"D1": fmt.Sprintf("%d.000000", now.AddDate(0, -3, 0).Unix())},
+		Threads:     map[string]map[string]string{"D1": {"1.000000": fmt.Sprintf("%d.000000", now.Add(-time.Hour).Unix())}},
+	}
+	if got := config.Tier(state.LastActivity("D1"), now); got != TierHot {
+		t.Errorf("Tier with recent thread reply = %s, want hot", got)
+	}
+
+	m := NewMonitor(nil, nil, nil, config)
+	delete(state.Threads, "D1")
+	conv := Conversation{ID: "D1", Type: ConversationTypeIM}
+	if _, due := m.isDue(conv, state, now); !due {
+		t.Error("Expected a conversation never polled by this process to be due")
+	}
+	m.lastPolled["D1"] = now.Add(-10 * time.Minute)
+	if tier, due := m.isDue(conv, state, now); tier != TierCold || due {
+		t.Errorf("isDue = %s, %v; want cold, not due", tier, due)
+	}
+	m.lastPolled["D1"] = now.Add(-31 * time.Minute)
+	if _, due := m.isDue(conv, state, now); !due {
+		t.Error("Expected a cold conversation to be due after its interval")
+	}
+
+	config.Monitor.TieredPolling = false
+	if got := config.Tier(now.AddDate(-1, 0, 0), now); got != TierHot {
+		t.Errorf("Tier without tiered polling = %s, want hot", got)
+	}
+}
+
+// TestApplyConfig tests that a reloaded configuration replaces the current one,
+// and that changed Slack tokens are authenticated before they are used
+func TestApplyConfig(t *testing.T) {
+	client := &stubSlackClient{userID: "U1"}
+	config := &Config{}
+	config.Slack.XoxcToken, config.Slack.XoxdToken = "xoxc-1", "xoxd-1"
+	m := NewMonitor(client, nil, nil, config)
+	ctx := context.Background()
+
+	same := *config
+	same.Slack.PollIntervalSecs = 30
+	m.Reload(&same, nil)
+	if err := m.applyConfig(ctx, <-m.reloads); err != nil {
+		t.Fatalf("applyConfig returned error: %v", err)
+	}
+	if m.config.Slack.PollIntervalSecs != 30 {
+		t.Errorf("Expected the reloaded poll interval, got %d", m.config.Slack.PollIntervalSecs)
+	}
+	if len(client.credentials) != 0 {
+		t.Errorf("Expected unchanged tokens to be left alone, got %v", client.credentials)
+	}
+
+	// Only the newest of several pending reloads is applied, as the user the
+	// new tokens belong to
+	rotated := same
+	rotated.Slack.XoxcToken = "xoxc-2"
+	client.userID = "U2"
+	applied := ""
+	m.Reload(&same, func() { applied += "same" })
+	m.Reload(&rotated, func() { applied += "rotated" })
+	if err := m.applyConfig(ctx, <-m.reloads); err != nil {
+		t.Fatalf("applyConfig returned error: %v", err)
+	}
+	if m.config != &rotated || applied != "rotated" {
+		t.Errorf("Expected the newest configuration to be applied, with its apply func only, got %q", applied)
+	}
+	if strings.Join(client.credentials, ",") != "xoxc-2/xoxd-1" {
+		t.Errorf("Expected new tokens to be set once, got %v", client.credentials)
+	}
+	if m.userID != "U2" {
+		t.Errorf("Expected the new tokens' user, got %s", m.userID)
+	}
+
+	// Tokens Slack rejects leave everything as it was
+	rejected := rotated
+	rejected.Slack.XoxcToken = "xoxc-3"
+	client.authErr = ErrSessionExpired
+	applied = ""
+	m.Reload(&rejected, func() { applied += "rejected" })
+	if err := m.applyConfig(ctx, <-m.reloads); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Expected the reload to be rejected, got %v", err)
+	}
+	if m.config != &rotated || applied != "" {
+		t.Errorf("Expected the current configuration to be kept, apply func ran: %q", applied)
+	}
+	if strings.Join(client.credentials, ",") != "xoxc-2/xoxd-1,xoxc-3/xoxd-1,xoxc-2/xoxd-1" {
+		t.Errorf("Expected the client back on the current tokens, got %v", client.credentials)
+	}
+}
+
+// countingSlackClient reports fixed rate limit counters
+type countingSlackClient struct {
+	stubSlackClient
+	stats RateLimitStats
+}
+
+func (c *countingSlackClient) RateLimitStats() RateLimitStats {
+	return c.stats
+}
+
+// TestLogRateLimits tests that each cycle logs only its own share of the
+// client's cumulative request counters
+func TestLogRateLimits(t *testing.T) {
+	var out strings.Builder
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	client := &countingSlackClient{stats: RateLimitStats{Requests: 10, RateLimited: 1, Retries: 1, Waited: 2 * time.Second}}
+	m := NewMonitor(client, nil, nil, &Config{})
+	m.logRateLimits()
+	client.stats.Requests = 14
+	m.logRateLimits()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 ||
+		!strings.HasSuffix(lines[0], "Slack API: 10 request(s), 1 rate limited, 1 retried, 2s spent waiting") ||
+		!strings.HasSuffix(lines[1], "Slack API: 4 request(s), 0 rate limited, 0 retried, 0s spent waiting") {
+		t.Errorf("Unexpected log output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// mrkdwnTokenPattern matches Slack's angle-bracket tokens: <@U1>, <#C1|name>, <!here>, <https://x|label>
+	mrkdwnTokenPattern = regexp.MustCompile(`<([^<>]+)>`)
+
+	// emojiPattern matches emoji shortcodes such as :tada: or :+1:
+	emojiPattern = regexp.MustCompile(`:([a-z0-9_+'-]+):`)
+)
+
+// renderMrkdwn converts Slack mrkdwn into plain text suitable for a phone
+// notification: user, channel and group references are resolved to names,
+// links become "label (url)", HTML entities are unescaped and common emoji
+// shortcodes are replaced with Unicode
+func (m *Monitor) renderMrkdwn(ctx context.Context, text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range mrkdwnTokenPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(renderPlainText(text[last:loc[0]]))
+		b.WriteString(m.renderToken(ctx, text[loc[2]:loc[3]]))
+		last = loc[1]
+	}
+	b.WriteString(renderPlainText(text[last:]))
+	return b.String()
+}
+
+// renderToken renders the inside of a single <...> token
+func (m *Monitor) renderToken(ctx context.Context, token string) string {
+	target, label, _ := strings.Cut(token, "|")
+	label = html.UnescapeString(label)
+
+	switch {
+	case strings.HasPrefix(target, "@"):
+		userID := target[1:]
+		name := m.getUserDisplayName(ctx, userID)
+		if name == userID && label != "" {
+			name = label
+		}
+		return "@" + name
+
+	case strings.HasPrefix(target, "#"):
+		if label != "" {
+			return "#" + label
+		}
+		return "#" + m.getChannelName(ctx, target[1:])
+
+	case strings.HasPrefix(target, "!"):
+		command := target[1:]
+		switch {
+		case command == "here" || command == "channel" || command == "everyone":
+			return "@" + command
+		case strings.HasPrefix(command, "subteam^"):
+			if label != "" {
+				return label
+			}
+			if handle, ok := m.userGroups[strings.TrimPrefix(command, "subteam^")]; ok {
+				return "@" + handle
+			}
+			return "@group"
+		default:
+			// <!date^...|fallback> and other special commands carry readable fallback text
+			return label
+		}
+
+	default:
+		link := html.UnescapeString(target)
+		display := strings.TrimPrefix(link, "mailto:")
+		if label == "" || label == link || label == display {
+			return display
+		}
+		return label + " (" + display + ")"
+	}
+}
+
+// renderPlainText unescapes entities and replaces emoji shortcodes in text outside of tokens
+func renderPlainText(text string) string {
+	text = emojiPattern.ReplaceAllStringFunc(text, func(code string) string {
+		name := code[1 : len(code)-1]
+		if strings.HasPrefix(name, "skin-tone-") {
+			return ""
+		}
+		if emoji, ok := emojiShortcodes[name]; ok {
+			return emoji
+		}
+		return code
+	})
+	return html.UnescapeString(text)
+}
+
+// getChannelName gets a channel's name (from cache or API)
+func (m *Monitor) getChannelName(ctx context.Context, channelID string) string {
+	// Check cache first
+	m.cacheMu.Lock()
+	name, exists := m.channelCache[channelID]
+	m.cacheMu.Unlock()
+	if exists {
+		return name
+	}
+
+	// Fetch from API
+	conv, err := m.slackClient.GetConversationInfo(ctx, channelID)
+	if err != nil || conv.Name == "" {
+		// Fallback to channel ID on error
+		return channelID
+	}
+
+	// Cache for future use
+	m.cacheMu.Lock()
+	m.channelCache[channelID] = conv.Name
+	m.cacheMu.Unlock()
+	return conv.Name
+}
+
+// emojiShortcodes maps common Slack emoji shortcodes to Unicode
+var emojiShortcodes = map[string]string{
+	"+1":                     "👍",
+	"-1":                     "👎",
+	"100":                    "💯",
+	"alarm_clock":            "⏰",
+	"bangbang":               "‼️",
+	"beer":                   "🍺",
+	"blush":                  "😊",
+	"boom":                   "💥",
+	"bug":                    "🐛",
+	"bulb":                   "💡",
+	"calendar":               "📆",
+	"checkered_flag":         "🏁",
+	"clap":                   "👏",
+	"coffee":                 "☕",
+	"confused":               "😕",
+	"cry":                    "😢",
+	"disappointed":           "😞",
+	"exclamation":            "❗",
+	"eyes":                   "👀",
+	"facepalm":               "🤦",
+	"fire":                   "🔥",
+	"ghost":                  "👻",
+	"grimacing":              "😬",
+	"grin":                   "😁",
+	"grinning":               "😀",
+	"heart":                  "❤️",
+	"heart_eyes":             "😍",
+	"heavy_check_mark":       "✔️",
+	"heavy_plus_sign":        "➕",
+	"hourglass":              "⌛",
+	"hugging_face":           "🤗",
+	"innocent":               "😇",
+	"joy":                    "😂",
+	"key":                    "🔑",
+	"laughing":               "😆",
+	"lock":                   "🔒",
+	"memo":                   "📝",
+	"muscle":                 "💪",
+	"neutral_face":           "😐",
+	"ok_hand":                "👌",
+	"partying_face":          "🥳",
+	"pensive":                "😔",
+	"pizza":                  "🍕",
+	"point_right":            "👉",
+	"point_up":               "☝️",
+	"pray":                   "🙏",
+	"question":               "❓",
+	"raised_hands":           "🙌",
+	"relieved":               "😌",
+	"rocket":                 "🚀",
+	"rofl":                   "🤣",
+	"rotating_light":         "🚨",
+	"scream":                 "😱",
+	"see_no_evil":            "🙈",
+	"shrug":                  "🤷",
+	"simple_smile":           "🙂",
+	"skull":                  "💀",
+	"slightly_frowning_face": "🙁",
+	"slightly_smiling_face":  "🙂",
+	"smile":                  "😄",
+	"smiley":                 "😃",
+	"smirk":                  "😏",
+	"sob":                    "😭",
+	"sos":                    "🆘",
+	"sparkles":               "✨",
+	"star":                   "⭐",
+	"stuck_out_tongue":       "😛",
+	"sunglasses":             "😎",
+	"sweat_smile":            "😅",
+	"tada":                   "🎉",
+	"thinking_face":          "🤔",
+	"thumbsdown":             "👎",
+	"thumbsup":               "👍",
+	"upside_down_face":       "🙃",
+	"warning":                "⚠️",
+	"wave":                   "👋",
+	"white_check_mark":       "✅",
+	"wink":                   "😉",
+	"x":                      "❌",
+	"zap":                    "⚡",
+}
@@ -0,0 +1,257 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Priority is a notification's urgency, on ntfy's scale of 1 (min) to 5 (urgent)
+type Priority int
+
+// Notification priorities. The zero value is treated as PriorityDefault.
+const (
+	PriorityMin Priority = iota + 1
+	PriorityLow
+	PriorityDefault
+	PriorityHigh
+	PriorityUrgent
+)
+
+// String returns the priority's name ("min", "low", "default", "high" or "urgent")
+func (p Priority) String() string {
+	switch p {
+	case PriorityMin:
+		return "min"
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	case PriorityUrgent:
+		return "urgent"
+	}
+	return "default"
+}
+
+// Notification describes a Slack message worth alerting the user about. Backends
+// that only deliver text use String(); richer backends use the fields directly.
+type Notification struct {
+	ID               string   // Delivery ID used to deduplicate (see DeliveryID)
+	Subject          string   // Title for notifications about the monitor itself; empty for messages
+	Sender           string   // Display name of the message author
+	SenderID         string   // User ID of the message author
+	ConversationID   string   // Channel ID the message was posted in
+	ConversationType string   // One of the ConversationType* constants
+	Channel          string   // Conversation label ("#deploys", "group DM (a, b)"); empty for DMs
+	TeamID           string   // Workspace ID, for slack:// deep links
+	Text             string   // Message text, rendered from mrkdwn
+	Timestamp        string   // Slack timestamp of the message
+	ThreadTS         string   // Parent timestamp if the message is a thread reply
+	Permalink        string   // Link to the message in the Slack web app, if known
+	Priority         Priority // Urgency (zero means default)
+	IsMention        bool     // Whether the message mentions the user
+	Mention          string   // How the user was mentioned ("you", "@here", "@oncall"), if at all
+	Tags             []string // Extra labels for backends that support them
+	Targets          []string // Only deliver to these notification targets, by name (empty = all)
+
+	Batch []Notification // Notifications summarized by this one, oldest first (see Summarize)
+}
+
+// DeliveryID returns the notification's ID, defaulting to its conversation and
+// Slack timestamp, which uniquely identify a message
+func (n Notification) DeliveryID() string {
+	if n.ID != "" {
+		return n.ID
+	}
+	if n.ConversationID == "" || n.Timestamp == "" {
+		return ""
+	}
+	return n.ConversationID + "/" + n.Timestamp
+}
+
+// IsDM reports whether the message was sent in a one-to-one direct message
+func (n Notification) IsDM() bool {
+	// A summary of several conversations has no channel but is not a DM
+	return n.Channel == "" && (len(n.Batch) == 0 || n.ConversationID != "")
+}
+
+// IsThreadReply reports whether the message is a reply in a thread
+func (n Notification) IsThreadReply() bool {
+	return n.ThreadTS != "" && n.ThreadTS != n.Timestamp
+}
+
+// Time returns when the message was posted
+func (n Notification) Time() time.Time {
+	return parseTimestamp(n.Timestamp)
+}
+
+// ConversationName returns a stable, human-readable name for the conversation,
+// e.g. "DM with Alice" or "#deploys"
+func (n Notification) ConversationName() string {
+	if n.Channel == "" && !n.IsDM() {
+		return "multiple conversations"
+	}
+	if n.IsDM() {
+		return "DM with " + n.Sender
+	}
+	return n.Channel
+}
+
+// AppLink returns a slack:// link that opens the conversation in the Slack app,
+// or "" if the workspace is unknown
+func (n Notification) AppLink() string {
+	if n.TeamID == "" || n.ConversationID == "" {
+		return ""
+	}
+	return fmt.Sprintf("slack://channel?team=%s&id=%s", url.QueryEscape(n.TeamID), url.QueryEscape(n.ConversationID))
+}
+
+// Title summarizes who sent the message and where, e.g. "DM from Alice" or
+// "Alice mentioned you in #deploys", or returns the Subject if set
+func (n Notification) Title() string {
+	switch {
+	case n.Subject != "":
+		return n.Subject
+	case len(n.Batch) == 1:
+		return fmt.Sprintf("1 new message from %s", n.Sender)
+	case len(n.Batch) > 0:
+		return fmt.Sprintf("%d new messages from %s", len(n.Batch), n.Sender)
+	case n.IsThreadReply() && n.IsDM():
+		return fmt.Sprintf("Thread reply from %s", n.Sender)
+	case n.IsThreadReply():
+		return fmt.Sprintf("%s replied in thread in %s", n.Sender, n.Channel)
+	case n.IsDM():
+		return fmt.Sprintf("DM from %s", n.Sender)
+	case n.Mention != "":
+		return fmt.Sprintf("%s mentioned %s in %s", n.Sender, n.Mention, n.Channel)
+	}
+	return fmt.Sprintf("%s in %s", n.Sender, n.Channel)
+}
+
+// Body returns the message text, shortened to a length suitable for a notification
+func (n Notification) Body() string {
+	return truncateMessage(n.Text)
+}
+
+// String returns the one-line notification text, e.g. "DM from Alice: are you there?"
+func (n Notification) String() string {
+	return n.Title() + ": " + n.Body()
+}
+
+// Summarize combines several notifications into one, e.g. "3 new messages from
+// Alice, Bob", whose text lists each message. The summary takes the highest
+// priority in the batch, and the conversation fields when every message shares
+// a conversation. Callers batch only notifications with the same targets (see
+// GroupByTargets).
+func Summarize(batch []Notification) Notification {
+	if len(batch) == 1 {
+		return batch[0]
+	}
+
+	var senders, lines []string
+	seenSender := make(map[string]bool)
+	seenTag := make(map[string]bool)
+	last := batch[len(batch)-1]
+	summary := Notification{
+		ConversationID:   last.ConversationID,
+		ConversationType: last.ConversationType,
+		Channel:          last.Channel,
+		TeamID:           last.TeamID,
+		Timestamp:        last.Timestamp,
+		Permalink:        last.Permalink,
+		Priority:         last.Priority,
+		Targets:          last.Targets,
+		Batch:            batch,
+	}
+	for _, n := range batch {
+		if !seenSender[n.Sender] {
+			seenSender[n.Sender] = true
+			senders = append(senders, n.Sender)
+		}
+		for _, tag := range n.Tags {
+			if !seenTag[tag] {
+				seenTag[tag] = true
+				summary.Tags = append(summary.Tags, tag)
+			}
+		}
+		lines = append(lines, n.Title()+": "+n.Text)
+		if n.Priority > summary.Priority {
+			summary.Priority = n.Priority
+		}
+		if n.IsMention {
+			summary.IsMention = true
+		}
+		if n.ConversationID != summary.ConversationID {
+			summary.ConversationID, summary.ConversationType, summary.Channel, summary.Permalink = "", "", "", ""
+		}
+	}
+	summary.Sender = strings.Join(senders, ", ")
+	summary.Text = strings.Join(lines, "\n")
+	return summary
+}
+
+// newNotification builds the notification for a message in conv. The caller
+// sets the mention and priority.
+func (m *Monitor) newNotification(ctx context.Context, conv Conversation, msg Message) Notification {
+	n := Notification{
+		Sender:           m.getUserDisplayName(ctx, msg.User),
+		SenderID:         msg.User,
+		ConversationID:   conv.ID,
+		ConversationType: conv.Type,
+		TeamID:           m.slackClient.GetTeamID(),
+		Text:             m.renderMrkdwn(ctx, msg.Text),
+		Timestamp:        msg.Timestamp,
+		Priority:         PriorityDefault,
+	}
+	if !conv.IsDM() {
+		n.Channel = channelLabel(conv)
+	}
+	if msg.ThreadTS != "" && msg.ThreadTS != msg.Timestamp {
+		n.ThreadTS = msg.ThreadTS
+	}
+	n.Permalink = permalink(m.slackClient.GetWorkspaceURL(), conv.ID, msg.Timestamp, n.ThreadTS)
+	return n
+}
+
+// permalink builds a web link to a message, or "" if the workspace URL is unknown
+func permalink(workspaceURL, channelID, ts, threadTS string) string {
+	if workspaceURL == "" || ts == "" {
+		return ""
+	}
+	link := fmt.Sprintf("%s/archives/%s/p%s", strings.TrimSuffix(workspaceURL, "/"), channelID, strings.Replace(ts, ".", "", 1))
+	if threadTS != "" {
+		link += "?" + url.Values{"thread_ts": {threadTS}, "cid": {channelID}}.Encode()
+	}
+	return link
+}
+
+// truncateMessage shortens long message text for notification, cutting on rune
+// boundaries so multi-byte characters and emoji are never split
+func truncateMessage(messageText string) string {
+	const maxLength = 500
+	runes := []rune(messageText)
+	if len(runes) > maxLength {
+		messageText = string(runes[:maxLength-3]) + "..."
+	}
+	return messageText
+}
+
+// GroupByTargets splits a batch into runs routed to the same targets, keeping
+// the order of first appearance
+func GroupByTargets(batch []Notification) [][]Notification {
+	var groups [][]Notification
+	index := make(map[string]int)
+	for _, n := range batch {
+		key := strings.Join(n.Targets, "\x00")
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], n)
+	}
+	return groups
+}
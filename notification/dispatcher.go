@@ -0,0 +1,217 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+const (
+	defaultMaxPerMinute    = 30              // Default delivery rate (one notification every 2s)
+	defaultBatchWindow     = time.Second     // How long to wait for more messages after the first of a burst
+	maxQueueLength         = 1000            // Pending notifications kept before the oldest are dropped
+	defaultShutdownTimeout = 5 * time.Second // How long Run spends flushing the queue on shutdown
+)
+
+// errQueueFull is reported to the delivery callback for notifications dropped from a full queue
+var errQueueFull = errors.New("notification queue full")
+
+// Dispatcher implements the monitor.Notifier interface by queueing
+// notifications and delivering them in the background at a bounded rate.
+// Notifications that queue up while the dispatcher waits are coalesced into a
+// single summary ("3 new messages from Alice, Bob") rather than dropped.
+type Dispatcher struct {
+	batchWindow     time.Duration
+	shutdownTimeout time.Duration
+
+	mu          sync.Mutex
+	notifier    monitor.Notifier
+	minInterval time.Duration // Minimum time between deliveries
+	queue       []monitor.Notification
+	pending     chan struct{}                                 // Signalled when the queue becomes non-empty
+	onDelivery  func(batch []monitor.Notification, err error) // Optional delivery result callback
+}
+
+// NewDispatcher creates a dispatcher that delivers to notifier at most
+// maxPerMinute times a minute (30 if maxPerMinute is not positive)
+func NewDispatcher(notifier monitor.Notifier, maxPerMinute int) *Dispatcher {
+	return &Dispatcher{
+		notifier:        notifier,
+		minInterval:     minInterval(maxPerMinute),
+		batchWindow:     defaultBatchWindow,
+		shutdownTimeout: defaultShutdownTimeout,
+		pending:         make(chan struct{}, 1),
+	}
+}
+
+// minInterval returns the time between deliveries for a rate of maxPerMinute
+// (30 if maxPerMinute is not positive)
+func minInterval(maxPerMinute int) time.Duration {
+	if maxPerMinute <= 0 {
+		maxPerMinute = defaultMaxPerMinute
+	}
+	return time.Minute / time.Duration(maxPerMinute)
+}
+
+// SetNotifier replaces the notifier and delivery rate, e.g. after the
+// configuration is reloaded. Queued notifications go to the new notifier.
+func (d *Dispatcher) SetNotifier(notifier monitor.Notifier, maxPerMinute int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifier = notifier
+	d.minInterval = minInterval(maxPerMinute)
+}
+
+// OnDelivery registers a callback that receives every queued notification
+// once its delivery has succeeded or failed. Failed notifications are then the
+// callback's responsibility (e.g. to retry); without a callback they are logged.
+func (d *Dispatcher) OnDelivery(fn func(batch []monitor.Notification, err error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onDelivery = fn
+}
+
+// SendNotification queues a notification for delivery and returns immediately
+func (d *Dispatcher) SendNotification(n monitor.Notification) error {
+	d.mu.Lock()
+	var dropped []monitor.Notification
+	if len(d.queue) >= maxQueueLength {
+		log.Printf("Notification queue full, dropping oldest notification: %s", d.queue[0])
+		dropped = d.queue[:1:1]
+		d.queue = d.queue[1:]
+	}
+	d.queue = append(d.queue, n)
+	onDelivery := d.onDelivery
+	d.mu.Unlock()
+
+	if dropped != nil && onDelivery != nil {
+		onDelivery(dropped, errQueueFull)
+	}
+
+	select {
+	case d.pending <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers queued notifications until ctx is cancelled, then makes a
+// final attempt to deliver anything still queued
+func (d *Dispatcher) Run(ctx context.Context) {
+	var lastSent time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			d.flush()
+			return
+		case <-d.pending:
+		}
+
+		// Give the rest of a burst (e.g. one poll cycle) a moment to arrive, and
+		// respect the rate limit; everything queued meanwhile is coalesced
+		d.mu.Lock()
+		interval := d.minInterval
+		d.mu.Unlock()
+		wait := d.batchWindow
+		if untilAllowed := time.Until(lastSent.Add(interval)); untilAllowed > wait {
+			wait = untilAllowed
+		}
+		select {
+		case <-ctx.Done():
+			d.flush()
+			return
+		case <-time.After(wait):
+		}
+
+		if d.deliver() {
+			lastSent = time.Now()
+		}
+	}
+}
+
+// Pending returns the number of queued notifications
+func (d *Dispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queue)
+}
+
+// deliver sends everything queued as one notification per set of targets,
+// reporting whether anything was sent
+func (d *Dispatcher) deliver() bool {
+	batch, notifier, onDelivery := d.take()
+	if len(batch) == 0 {
+		return false
+	}
+	for _, group := range monitor.GroupByTargets(batch) {
+		sendGroup(notifier, onDelivery, group)
+	}
+	return true
+}
+
+// take empties the queue, returning what was in it along with the notifier
+// and callback to deliver it with
+func (d *Dispatcher) take() ([]monitor.Notification, monitor.Notifier, func([]monitor.Notification, error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	batch := d.queue
+	d.queue = nil
+	if len(batch) > 1 {
+		log.Printf("Coalescing %d notifications into one", len(batch))
+	}
+	return batch, d.notifier, d.onDelivery
+}
+
+// sendGroup delivers notifications with the same targets as one summary, and
+// passes the result to onDelivery, or logs failures without one
+func sendGroup(notifier monitor.Notifier, onDelivery func([]monitor.Notification, error), group []monitor.Notification) {
+	err := notifier.SendNotification(monitor.Summarize(group))
+	if onDelivery != nil {
+		onDelivery(group, err)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to deliver notification: %v", err)
+		for _, n := range group {
+			log.Printf("  Undelivered: %s", n)
+		}
+	}
+}
+
+// flush delivers whatever is queued at shutdown, giving up after
+// shutdownTimeout. Each notification not delivered by then is logged; with a
+// delivery callback (the outbox), it is still pending there for the next start.
+func (d *Dispatcher) flush() {
+	batch, notifier, onDelivery := d.take()
+	if len(batch) == 0 {
+		return
+	}
+	groups := monitor.GroupByTargets(batch)
+	var sent atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		for _, group := range groups {
+			sendGroup(notifier, onDelivery, group)
+			sent.Add(1)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(d.shutdownTimeout):
+		var undelivered []monitor.Notification
+		for _, group := range groups[sent.Load():] {
+			undelivered = append(undelivered, group...)
+		}
+		log.Printf("Timed out delivering queued notifications at shutdown, %d not delivered:", len(undelivered))
+		for _, n := range undelivered {
+			log.Printf("  Undelivered: %s (conversation %s, id %s)", n, n.ConversationID, n.DeliveryID())
+		}
+	}
+}
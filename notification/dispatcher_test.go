@@ -0,0 +1,215 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// collectingNotifier records delivered notifications
+type collectingNotifier struct {
+	mu        sync.Mutex
+	delivered []monitor.Notification
+	times     []time.Time
+	err       error
+}
+
+func (c *collectingNotifier) SendNotification(n monitor.Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delivered = append(c.delivered, n)
+	c.times = append(c.times, time.Now())
+	return c.err
+}
+
+func (c *collectingNotifier) snapshot() []monitor.Notification {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]monitor.Notification(nil), c.delivered...)
+}
+
+// newTestDispatcher creates a dispatcher with short intervals for tests
+func newTestDispatcher(notifier monitor.Notifier, minInterval time.Duration) *Dispatcher {
+	d := NewDispatcher(notifier, 1)
+	d.minInterval = minInterval
+	d.batchWindow = 20 * time.Millisecond
+	return d
+}
+
+// waitFor polls until cond is true or fails the test
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestDispatcherCoalescesBursts tests that a burst becomes a single summary notification
+func TestDispatcherCoalescesBursts(t *testing.T) {
+	target := &collectingNotifier{}
+	d := newTestDispatcher(target, 50*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.SendNotification(monitor.Notification{Sender: "Alice", Text: "one", Priority: monitor.PriorityDefault})
+	d.SendNotification(monitor.Notification{Sender: "Bob", Text: "two", Priority: monitor.PriorityHigh})
+	d.SendNotification(monitor.Notification{Sender: "Alice", Text: "three", Priority: monitor.PriorityDefault})
+
+	waitFor(t, func() bool { return len(target.snapshot()) == 1 })
+	got := target.snapshot()[0]
+	if want := "3 new messages from Alice, Bob"; got.Title() != want {
+		t.Errorf("Expected title %q, got %q", want, got.Title())
+	}
+	if want := "DM from Alice: one\nDM from Bob: two\nDM from Alice: three"; got.Text != want {
+		t.Errorf("Expected text %q, got %q", want, got.Text)
+	}
+	if got.Priority != monitor.PriorityHigh {
+		t.Errorf("Expected highest priority in batch, got %v", got.Priority)
+	}
+}
+
+// TestDispatcherRespectsRate tests that deliveries are spaced by the minimum interval
+func TestDispatcherRespectsRate(t *testing.T) {
+	target := &collectingNotifier{}
+	d := newTestDispatcher(target, 150*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.SendNotification(monitor.Notification{Sender: "Alice", Text: "first"})
+	waitFor(t, func() bool { return len(target.snapshot()) == 1 })
+	d.SendNotification(monitor.Notification{Sender: "Bob", Text: "second"})
+	waitFor(t, func() bool { return len(target.snapshot()) == 2 })
+
+	target.mu.Lock()
+	gap := target.times[1].Sub(target.times[0])
+	target.mu.Unlock()
+	if gap < 150*time.Millisecond {
+		t.Errorf("Expected deliveries at least 150ms apart, got %v", gap)
+	}
+	if got := target.snapshot()[1].String(); got != "DM from Bob: second" {
+		t.Errorf("Expected a single notification to be delivered unchanged, got %q", got)
+	}
+}
+
+// TestDispatcherFlushesOnShutdown tests that queued notifications are delivered when Run stops
+func TestDispatcherFlushesOnShutdown(t *testing.T) {
+	target := &collectingNotifier{err: errors.New("down")}
+	d := newTestDispatcher(target, time.Hour)
+	d.SendNotification(monitor.Notification{Sender: "Alice", Text: "late"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+
+	if got := target.snapshot(); len(got) != 1 || got[0].Text != "late" {
+		t.Errorf("Expected queued notification to be flushed, got %v", got)
+	}
+	if d.Pending() != 0 {
+		t.Errorf("Expected empty queue after flush, got %d", d.Pending())
+	}
+}
+
+// blockingNotifier never finishes a send until released
+type blockingNotifier struct {
+	release chan struct{}
+}
+
+func (b *blockingNotifier) SendNotification(n monitor.Notification) error {
+	<-b.release
+	return nil
+}
+
+// TestDispatcherLogsUndeliveredOnShutdown tests that each notification still
+// undelivered when the shutdown flush times out is logged
+func TestDispatcherLogsUndeliveredOnShutdown(t *testing.T) {
+	var out strings.Builder
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	target := &blockingNotifier{release: make(chan struct{})}
+	defer close(target.release)
+	d := newTestDispatcher(target, time.Hour)
+	d.shutdownTimeout = 20 * time.Millisecond
+	d.SendNotification(monitor.Notification{Sender: "Alice", ConversationID: "D1", Timestamp: "1.000000", Text: "stuck"})
+	d.SendNotification(monitor.Notification{Sender: "Bob", ConversationID: "D2", Timestamp: "2.000000", Text: "by email", Targets: []string{"email"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+
+	logged := out.String()
+	for _, want := range []string{
+		"2 not delivered",
+		"Undelivered: DM from Alice: stuck (conversation D1, id D1/1.000000)",
+		"Undelivered: DM from Bob: by email (conversation D2, id D2/2.000000)",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("Expected %q in log output:\n%s", want, logged)
+		}
+	}
+}
+
+// TestDispatcherGroupsByTargets tests that a burst is coalesced separately per set of targets
+func TestDispatcherGroupsByTargets(t *testing.T) {
+	target := &collectingNotifier{}
+	d := newTestDispatcher(target, 50*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.SendNotification(monitor.Notification{Sender: "Alice", Text: "one"})
+	d.SendNotification(monitor.Notification{Sender: "Bob", Text: "urgent", Targets: []string{"phone"}})
+	d.SendNotification(monitor.Notification{Sender: "Carol", Text: "two"})
+
+	waitFor(t, func() bool { return len(target.snapshot()) == 2 })
+	delivered := target.snapshot()
+	if len(delivered[0].Batch) != 2 || len(delivered[0].Targets) != 0 {
+		t.Errorf("Expected the untargeted messages coalesced first, got %+v", delivered[0])
+	}
+	if delivered[1].Text != "urgent" || len(delivered[1].Targets) != 1 {
+		t.Errorf("Expected the routed message delivered on its own, got %+v", delivered[1])
+	}
+}
+
+// TestNewDispatcherDefaultRate tests the default delivery rate
+func TestNewDispatcherDefaultRate(t *testing.T) {
+	if d := NewDispatcher(&collectingNotifier{}, 0); d.minInterval != 2*time.Second {
+		t.Errorf("Expected default interval 2s, got %v", d.minInterval)
+	}
+	if d := NewDispatcher(&collectingNotifier{}, 6); d.minInterval != 10*time.Second {
+		t.Errorf("Expected interval 10s for 6/minute, got %v", d.minInterval)
+	}
+}
+
+// TestDispatcherSetNotifier tests that queued notifications go to a replaced notifier at its rate
+func TestDispatcherSetNotifier(t *testing.T) {
+	old, replacement := &collectingNotifier{}, &collectingNotifier{}
+	d := newTestDispatcher(old, 50*time.Millisecond)
+	d.SendNotification(monitor.Notification{Sender: "Alice", Text: "one"})
+
+	d.SetNotifier(replacement, 6)
+	if d.minInterval != 10*time.Second {
+		t.Errorf("Expected interval 10s for 6/minute, got %v", d.minInterval)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	waitFor(t, func() bool { return len(replacement.snapshot()) == 1 })
+	if len(old.snapshot()) != 0 {
+		t.Errorf("Expected nothing delivered to the replaced notifier, got %+v", old.snapshot())
+	}
+}
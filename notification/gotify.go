@@ -0,0 +1,83 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// Gotify implements the monitor.Notifier interface using a Gotify server
+type Gotify struct {
+	serverURL  string // Base URL of the Gotify server
+	token      string // Application token
+	priority   int    // Priority for default-priority notifications
+	httpClient *http.Client
+}
+
+// newGotify creates a Gotify notifier from a notification target
+func newGotify(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	var settings struct {
+		URL      string `json:"url"`
+		Token    string `json:"token"`
+		Priority *int   `json:"priority"`
+	}
+	if err := decodeSettings(target, &settings); err != nil {
+		return nil, err
+	}
+	if settings.URL == "" || settings.Token == "" {
+		return nil, fmt.Errorf("url and token are required")
+	}
+
+	priority := 5 // Gotify's default for a normal push notification
+	if settings.Priority != nil {
+		priority = *settings.Priority
+	}
+
+	return &Gotify{
+		serverURL:  strings.TrimSuffix(settings.URL, "/"),
+		token:      settings.Token,
+		priority:   priority,
+		httpClient: newHTTPClient(),
+	}, nil
+}
+
+// SendNotification sends a notification to the Gotify server
+func (g *Gotify) SendNotification(n monitor.Notification) error {
+	body := map[string]interface{}{
+		"title":    n.Title(),
+		"message":  n.Body(),
+		"priority": g.priorityFor(n.Priority),
+	}
+	if n.Permalink != "" {
+		body["extras"] = map[string]interface{}{
+			"client::notification": map[string]interface{}{
+				"click": map[string]string{"url": n.Permalink},
+			},
+		}
+	}
+	headers := map[string]string{"X-Gotify-Key": g.token}
+	return postJSON(g.httpClient, "POST", g.serverURL+"/message", headers, body, "gotify")
+}
+
+// priorityFor maps a priority onto Gotify's 0..10 scale, centred on the configured priority
+func (g *Gotify) priorityFor(p monitor.Priority) int {
+	switch p {
+	case monitor.PriorityMin:
+		return 0
+	case monitor.PriorityLow:
+		if g.priority > 3 {
+			return g.priority - 3
+		}
+		return 1
+	case monitor.PriorityHigh:
+		if g.priority < 6 {
+			return g.priority + 3
+		}
+		return 9
+	case monitor.PriorityUrgent:
+		return 10
+	}
+	return g.priority
+}
@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// Matrix implements the monitor.Notifier interface by posting to a Matrix room
+type Matrix struct {
+	homeserver  string
+	accessToken string
+	roomID      string
+	txnCounter  atomic.Int64 // Makes transaction IDs unique within this process
+	httpClient  *http.Client
+}
+
+// newMatrix creates a Matrix notifier from a notification target
+func newMatrix(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	var settings struct {
+		Homeserver  string `json:"homeserver"`
+		AccessToken string `json:"access_token"`
+		RoomID      string `json:"room_id"`
+	}
+	if err := decodeSettings(target, &settings); err != nil {
+		return nil, err
+	}
+	if settings.Homeserver == "" || settings.AccessToken == "" || settings.RoomID == "" {
+		return nil, fmt.Errorf("homeserver, access_token and room_id are required")
+	}
+
+	return &Matrix{
+		homeserver:  strings.TrimSuffix(settings.Homeserver, "/"),
+		accessToken: settings.AccessToken,
+		roomID:      settings.RoomID,
+		httpClient:  newHTTPClient(),
+	}, nil
+}
+
+// SendNotification sends an m.text message to the configured room
+func (m *Matrix) SendNotification(n monitor.Notification) error {
+	txnID := fmt.Sprintf("slack-monitor-%d-%d", time.Now().UnixNano(), m.txnCounter.Add(1))
+	sendURL := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.homeserver, url.PathEscape(m.roomID), txnID)
+
+	body := map[string]string{
+		"msgtype": "m.text",
+		"body":    n.String(),
+	}
+	headers := map[string]string{"Authorization": "Bearer " + m.accessToken}
+	return postJSON(m.httpClient, "PUT", sendURL, headers, body, "matrix")
+}
@@ -0,0 +1,94 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// namedNotifier pairs a notifier with the target name used in logs
+type namedNotifier struct {
+	name     string
+	notifier monitor.Notifier
+}
+
+// Multi implements the monitor.Notifier interface by fanning out to several
+// targets concurrently. A failing target never blocks or suppresses the others.
+type Multi struct {
+	targets []namedNotifier
+}
+
+// Add appends a target to the fan-out
+func (m *Multi) Add(name string, notifier monitor.Notifier) {
+	m.targets = append(m.targets, namedNotifier{name: name, notifier: notifier})
+}
+
+// Len returns the number of targets
+func (m *Multi) Len() int {
+	return len(m.targets)
+}
+
+// Names returns the target names, in the order they were added
+func (m *Multi) Names() []string {
+	names := make([]string, len(m.targets))
+	for i, target := range m.targets {
+		names[i] = target.name
+	}
+	return names
+}
+
+// SendNotification sends the notification to every target, or to the targets
+// it names. Each failure is logged; an error is returned only if no target
+// accepted the message.
+func (m *Multi) SendNotification(n monitor.Notification) error {
+	targets := m.selectTargets(n.Targets)
+	if len(targets) == 0 {
+		return fmt.Errorf("%w %s", monitor.ErrUnknownTarget, strings.Join(n.Targets, ", "))
+	}
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target namedNotifier) {
+			defer wg.Done()
+			if err := target.notifier.SendNotification(n); err != nil {
+				log.Printf("Notification target %s failed: %v", target.name, err)
+				errs[i] = fmt.Errorf("%s: %w", target.name, err)
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 && failed == len(targets) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// selectTargets returns the targets with the given names, or all targets if none are given
+func (m *Multi) selectTargets(names []string) []namedNotifier {
+	if len(names) == 0 {
+		return m.targets
+	}
+	var selected []namedNotifier
+	for _, target := range m.targets {
+		for _, name := range names {
+			if target.name == name {
+				selected = append(selected, target)
+				break
+			}
+		}
+	}
+	return selected
+}
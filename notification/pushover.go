@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+const defaultPushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Pushover implements the monitor.Notifier interface using the Pushover API
+type Pushover struct {
+	apiURL     string
+	token      string // Application API token
+	user       string // User or group key
+	device     string // Optional device name
+	httpClient *http.Client
+}
+
+// newPushover creates a Pushover notifier from a notification target
+func newPushover(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	var settings struct {
+		Token  string `json:"token"`
+		User   string `json:"user"`
+		Device string `json:"device"`
+		APIURL string `json:"api_url"`
+	}
+	if err := decodeSettings(target, &settings); err != nil {
+		return nil, err
+	}
+	if settings.Token == "" || settings.User == "" {
+		return nil, fmt.Errorf("token and user are required")
+	}
+	if settings.APIURL == "" {
+		settings.APIURL = defaultPushoverURL
+	}
+
+	return &Pushover{
+		apiURL:     settings.APIURL,
+		token:      settings.Token,
+		user:       settings.User,
+		device:     settings.Device,
+		httpClient: newHTTPClient(),
+	}, nil
+}
+
+// SendNotification sends a notification through Pushover
+func (p *Pushover) SendNotification(n monitor.Notification) error {
+	form := url.Values{}
+	form.Set("token", p.token)
+	form.Set("user", p.user)
+	form.Set("title", n.Title())
+	form.Set("message", n.Body())
+	form.Set("priority", strconv.Itoa(pushoverPriority(n.Priority)))
+	if n.Timestamp != "" {
+		form.Set("timestamp", strconv.FormatInt(n.Time().Unix(), 10))
+	}
+	if n.Permalink != "" {
+		form.Set("url", n.Permalink)
+		form.Set("url_title", "Open in Slack")
+	}
+	if p.device != "" {
+		form.Set("device", p.device)
+	}
+
+	req, err := http.NewRequest("POST", p.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create pushover notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doRequest(p.httpClient, req, "pushover")
+}
+
+// pushoverPriority maps a priority onto Pushover's -2..1 scale. Pushover's
+// emergency level (2) requires acknowledgement, so urgent stops at high.
+func pushoverPriority(p monitor.Priority) int {
+	switch p {
+	case monitor.PriorityMin:
+		return -2
+	case monitor.PriorityLow:
+		return -1
+	case monitor.PriorityHigh, monitor.PriorityUrgent:
+		return 1
+	}
+	return 0
+}
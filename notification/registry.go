@@ -0,0 +1,158 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// Factory creates a notifier from a target's configuration
+type Factory func(target monitor.NotificationTarget) (monitor.Notifier, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"ntfy":     newNtfyTarget,
+		"pushover": newPushover,
+		"gotify":   newGotify,
+		"telegram": newTelegram,
+		"matrix":   newMatrix,
+		"smtp":     newSMTP,
+		"webhook":  newWebhook,
+	}
+)
+
+// Register makes a backend available under the given type name, replacing any
+// existing backend with that name
+func Register(backendType string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[backendType] = factory
+}
+
+// Types returns the registered backend type names, sorted
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New creates the notifier for a single target
+func New(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	registryMu.RLock()
+	factory, ok := registry[target.Type]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown notification type %q (available: %s)", target.Type, strings.Join(Types(), ", "))
+	}
+
+	notifier, err := factory(target)
+	if err != nil {
+		return nil, fmt.Errorf("notification target %s: %w", target.DisplayName(), err)
+	}
+	return notifier, nil
+}
+
+// NewFromConfig creates a notifier that fans out to every configured target.
+// The notifications.ntfy_topic shorthand is treated as an extra ntfy target.
+func NewFromConfig(config *monitor.Config) (*Multi, error) {
+	targets := config.Notifications.Targets
+	if config.Notifications.NtfyTopic != "" {
+		settings, _ := json.Marshal(map[string]string{
+			"type":   "ntfy",
+			"topic":  config.Notifications.NtfyTopic,
+			"server": config.Notifications.NtfyServer,
+			"token":  config.Notifications.NtfyToken,
+		})
+		targets = append([]monitor.NotificationTarget{{Type: "ntfy", Settings: settings}}, targets...)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no notification targets configured")
+	}
+
+	multi := &Multi{}
+	for _, target := range targets {
+		notifier, err := New(target)
+		if err != nil {
+			return nil, err
+		}
+		multi.Add(target.DisplayName(), notifier)
+	}
+	return multi, nil
+}
+
+// decodeSettings unmarshals a target's backend-specific settings into v
+func decodeSettings(target monitor.NotificationTarget, v interface{}) error {
+	if len(target.Settings) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(target.Settings, v); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	return nil
+}
+
+// newHTTPClient returns the HTTP client used by HTTP-based backends
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+	}
+}
+
+// doRequest sends a request and returns an error for any non-2xx response
+func doRequest(client *http.Client, req *http.Request, backend string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", backend, withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned status %d: %s", backend, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// withoutURL strips the request URL from an HTTP client error. Telegram's and
+// many webhook URLs carry secrets, and errors end up in logs and the outbox.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// postJSON sends v as a JSON request body
+func postJSON(client *http.Client, method, rawURL string, headers map[string]string, v interface{}, backend string) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s notification: %w", backend, err)
+	}
+
+	req, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s notification request: %w", backend, withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return doRequest(client, req, backend)
+}
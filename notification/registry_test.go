@@ -0,0 +1,318 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// parseTarget builds a NotificationTarget from its JSON config form
+func parseTarget(t *testing.T, raw string) monitor.NotificationTarget {
+	t.Helper()
+	var target monitor.NotificationTarget
+	if err := json.Unmarshal([]byte(raw), &target); err != nil {
+		t.Fatalf("Failed to parse target: %v", err)
+	}
+	return target
+}
+
+// testNotification is a DM notification used across backend tests
+var testNotification = monitor.Notification{
+	Sender:         "Alice",
+	SenderID:       "U1",
+	ConversationID: "D1",
+	TeamID:         "T1",
+	Text:           "hi",
+	Timestamp:      "1700000000.000100",
+	Permalink:      "https://acme.slack.com/archives/D1/p1700000000000100",
+	Priority:       monitor.PriorityDefault,
+}
+
+// capturedRequest records what a backend sent to the test server
+type capturedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+// newCaptureServer starts a server that records the last request and replies with status
+func newCaptureServer(t *testing.T, status int) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	captured := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*captured = capturedRequest{method: r.Method, path: r.URL.Path, header: r.Header, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, captured
+}
+
+// TestHTTPBackends tests the request each HTTP backend sends
+func TestHTTPBackends(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string // %s is replaced with the test server URL
+		wantMethod string
+		wantPath   string
+		wantHeader [2]string
+		wantBody   string
+	}{
+		{
+			name:       "pushover",
+			config:     `{"type": "pushover", "token": "app", "user": "me", "api_url": "%s/1/messages.json"}`,
+			wantMethod: "POST",
+			wantPath:   "/1/messages.json",
+			wantBody:   "message=hi&priority=0&timestamp=1700000000&title=DM+from+Alice&token=app",
+		},
+		{
+			name:       "gotify",
+			config:     `{"type": "gotify", "url": "%s/", "token": "secret"}`,
+			wantMethod: "POST",
+			wantPath:   "/message",
+			wantHeader: [2]string{"X-Gotify-Key", "secret"},
+			wantBody:   `"message":"hi"`,
+		},
+		{
+			name:       "telegram",
+			config:     `{"type": "telegram", "bot_token": "123:abc", "chat_id": "42", "api_url": "%s"}`,
+			wantMethod: "POST",
+			wantPath:   "/bot123:abc/sendMessage",
+			wantBody:   `"chat_id":"42"`,
+		},
+		{
+			name:       "matrix",
+			config:     `{"type": "matrix", "homeserver": "%s", "access_token": "tok", "room_id": "!room:example.org"}`,
+			wantMethod: "PUT",
+			wantPath:   "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/",
+			wantHeader: [2]string{"Authorization", "Bearer tok"},
+			wantBody:   `"msgtype":"m.text"`,
+		},
+		{
+			name:       "webhook",
+			config:     `{"type": "webhook", "url": "%s/hook", "headers": {"X-Api-Key": "k"}}`,
+			wantMethod: "POST",
+			wantPath:   "/hook",
+			wantHeader: [2]string{"X-Api-Key", "k"},
+			wantBody:   `"text":"DM from Alice: hi"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, captured := newCaptureServer(t, http.StatusOK)
+			notifier, err := New(parseTarget(t, strings.Replace(tt.config, "%s", server.URL, 1)))
+			if err != nil {
+				t.Fatalf("New returned error: %v", err)
+			}
+
+			if err := notifier.SendNotification(testNotification); err != nil {
+				t.Fatalf("SendNotification returned error: %v", err)
+			}
+			if captured.method != tt.wantMethod {
+				t.Errorf("Expected method %s, got %s", tt.wantMethod, captured.method)
+			}
+			if !strings.HasPrefix(captured.path, tt.wantPath) {
+				t.Errorf("Expected path %s, got %s", tt.wantPath, captured.path)
+			}
+			if tt.wantHeader[0] != "" && captured.header.Get(tt.wantHeader[0]) != tt.wantHeader[1] {
+				t.Errorf("Expected header %s=%s, got %q", tt.wantHeader[0], tt.wantHeader[1], captured.header.Get(tt.wantHeader[0]))
+			}
+			if !strings.Contains(captured.body, tt.wantBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.wantBody, captured.body)
+			}
+		})
+	}
+}
+
+// TestHTTPBackendErrorStatus tests that non-2xx responses are reported as errors
+func TestHTTPBackendErrorStatus(t *testing.T) {
+	server, _ := newCaptureServer(t, http.StatusUnauthorized)
+	notifier, err := New(parseTarget(t, `{"type": "webhook", "url": "`+server.URL+`"}`))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if err := notifier.SendNotification(testNotification); err == nil {
+		t.Error("Expected error for 401 response")
+	}
+}
+
+// TestHTTPBackendErrorRedactsURL tests that transport errors don't reveal
+// secrets carried in the request URL
+func TestHTTPBackendErrorRedactsURL(t *testing.T) {
+	server, _ := newCaptureServer(t, http.StatusOK)
+	server.Close()
+	for _, config := range []string{
+		`{"type": "telegram", "bot_token": "123:SECRET", "chat_id": "42", "api_url": "` + server.URL + `"}`,
+		`{"type": "webhook", "url": "` + server.URL + `/hooks/SECRET"}`,
+	} {
+		notifier, err := New(parseTarget(t, config))
+		if err != nil {
+			t.Fatalf("New returned error: %v", err)
+		}
+		err = notifier.SendNotification(testNotification)
+		if err == nil || strings.Contains(err.Error(), "SECRET") {
+			t.Errorf("Expected an error without the secret, got %v", err)
+		}
+	}
+}
+
+// TestSMTPBackend tests the email built by the SMTP backend
+func TestSMTPBackend(t *testing.T) {
+	notifier, err := New(parseTarget(t, `{"type": "smtp", "host": "mail.example.com", "from": "monitor@example.com", "to": ["me@example.com"]}`))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	var gotAddr string
+	var gotMsg []byte
+	s := notifier.(*SMTP)
+	s.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotMsg = addr, msg
+		return nil
+	}
+
+	if err := s.SendNotification(testNotification); err != nil {
+		t.Fatalf("SendNotification returned error: %v", err)
+	}
+	if gotAddr != "mail.example.com:587" {
+		t.Errorf("Expected default port 587, got %s", gotAddr)
+	}
+	for _, want := range []string{
+		"Subject: Slack: DM with Alice\r\n",
+		"References: <T1.D1@example.com>\r\n",
+		"DM from Alice: hi\r\n",
+	} {
+		if !strings.Contains(string(gotMsg), want) {
+			t.Errorf("Expected email to contain %q, got:\n%s", want, gotMsg)
+		}
+	}
+}
+
+// TestNewValidation tests unknown types and missing required settings
+func TestNewValidation(t *testing.T) {
+	tests := []string{
+		`{"type": "carrier-pigeon"}`,
+		`{"type": "ntfy"}`,
+		`{"type": "pushover", "token": "app"}`,
+		`{"type": "gotify", "url": "https://gotify.example.com"}`,
+		`{"type": "telegram", "chat_id": "42"}`,
+		`{"type": "matrix", "homeserver": "https://matrix.org"}`,
+		`{"type": "smtp", "host": "mail.example.com"}`,
+		`{"type": "webhook"}`,
+	}
+	for _, raw := range tests {
+		if _, err := New(parseTarget(t, raw)); err == nil {
+			t.Errorf("Expected error for %s", raw)
+		}
+	}
+}
+
+// TestNewFromConfig tests that the legacy ntfy_topic is combined with targets
+func TestNewFromConfig(t *testing.T) {
+	config := &monitor.Config{}
+	if _, err := NewFromConfig(config); err == nil {
+		t.Error("Expected error with no targets")
+	}
+
+	config.Notifications.NtfyTopic = "legacy-topic"
+	config.Notifications.Targets = []monitor.NotificationTarget{
+		parseTarget(t, `{"type": "webhook", "url": "https://example.com/hook"}`),
+	}
+	multi, err := NewFromConfig(config)
+	if err != nil {
+		t.Fatalf("NewFromConfig returned error: %v", err)
+	}
+	if multi.Len() != 2 {
+		t.Errorf("Expected 2 targets, got %d", multi.Len())
+	}
+}
+
+// notifierFunc adapts a function to the monitor.Notifier interface
+type notifierFunc func(n monitor.Notification) error
+
+func (f notifierFunc) SendNotification(n monitor.Notification) error {
+	return f(n)
+}
+
+// TestMultiFanOut tests that one failing target does not affect the others
+func TestMultiFanOut(t *testing.T) {
+	delivered := make(chan monitor.Notification, 1)
+	multi := &Multi{}
+	multi.Add("broken", notifierFunc(func(monitor.Notification) error { return errors.New("down") }))
+	multi.Add("working", notifierFunc(func(n monitor.Notification) error {
+		delivered <- n
+		return nil
+	}))
+
+	if err := multi.SendNotification(testNotification); err != nil {
+		t.Errorf("Expected no error when one target succeeds, got: %v", err)
+	}
+	if got := <-delivered; got.Text != "hi" {
+		t.Errorf("Expected working target to receive message, got %q", got)
+	}
+
+	failing := &Multi{}
+	failing.Add("broken", notifierFunc(func(monitor.Notification) error { return errors.New("down") }))
+	if err := failing.SendNotification(testNotification); err == nil {
+		t.Error("Expected error when every target fails")
+	}
+}
+
+// TestMultiRoutesToTargets tests that a notification naming targets only reaches those targets
+func TestMultiRoutesToTargets(t *testing.T) {
+	var got []string
+	var mu sync.Mutex
+	record := func(name string) monitor.Notifier {
+		return notifierFunc(func(monitor.Notification) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, name)
+			return nil
+		})
+	}
+	multi := &Multi{}
+	multi.Add("phone", record("phone"))
+	multi.Add("email", record("email"))
+
+	n := testNotification
+	n.Targets = []string{"email"}
+	if err := multi.SendNotification(n); err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	if len(got) != 1 || got[0] != "email" {
+		t.Errorf("Expected delivery to email only, got %v", got)
+	}
+
+	n.Targets = []string{"pager"}
+	if err := multi.SendNotification(n); err == nil {
+		t.Error("Expected error when no target has the routed name")
+	}
+}
+
+// TestRegister tests registering a custom backend
+func TestRegister(t *testing.T) {
+	Register("test-backend", func(target monitor.NotificationTarget) (monitor.Notifier, error) {
+		return notifierFunc(func(monitor.Notification) error { return nil }), nil
+	})
+	if _, err := New(parseTarget(t, `{"type": "test-backend"}`)); err != nil {
+		t.Errorf("Expected registered backend to be available, got: %v", err)
+	}
+
+	found := false
+	for _, name := range Types() {
+		found = found || name == "test-backend"
+	}
+	if !found {
+		t.Errorf("Expected Types to include test-backend, got %v", Types())
+	}
+}
@@ -4,54 +4,126 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
-)
 
-const (
-	rateLimitSeconds = 2 // Minimum seconds between notifications
+	"github.com/FourPalms/golang-slack-monitor"
 )
 
-// Service implements the monitor.Notifier interface
+const defaultNtfyServer = "https://ntfy.sh" // Public ntfy server
+
+// Service implements the monitor.Notifier interface using ntfy
 type Service struct {
+	serverURL  string // ntfy server root, e.g. "https://ntfy.sh"
 	ntfyTopic  string
+	token      string   // Access token (Bearer auth)
+	username   string   // Basic auth username
+	password   string   // Basic auth password
+	tags       []string // Extra tags added to every notification
+	muteURL    string   // Endpoint for the "Mute 1h" action; {conversation} is replaced
 	httpClient *http.Client
-	lastNotify time.Time // For rate limiting
+}
+
+// Option configures a Service
+type Option func(*Service)
+
+// WithServer sets the ntfy server URL (default https://ntfy.sh)
+func WithServer(serverURL string) Option {
+	return func(s *Service) {
+		if serverURL != "" {
+			s.serverURL = strings.TrimSuffix(serverURL, "/")
+		}
+	}
+}
+
+// WithToken authenticates with an ntfy access token
+func WithToken(token string) Option {
+	return func(s *Service) {
+		s.token = token
+	}
+}
+
+// WithBasicAuth authenticates with an ntfy username and password
+func WithBasicAuth(username, password string) Option {
+	return func(s *Service) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithTags adds tags to every notification
+func WithTags(tags ...string) Option {
+	return func(s *Service) {
+		s.tags = append(s.tags, tags...)
+	}
+}
+
+// WithMuteURL adds a "Mute 1h" action that POSTs to muteURL. "{conversation}"
+// in the URL is replaced with the conversation ID.
+func WithMuteURL(muteURL string) Option {
+	return func(s *Service) {
+		s.muteURL = muteURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to reach the ntfy server
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Service) {
+		s.httpClient = client
+	}
 }
 
 // NewService creates a new notification service
-func NewService(ntfyTopic string) *Service {
-	return &Service{
+func NewService(ntfyTopic string, opts ...Option) *Service {
+	s := &Service{
+		serverURL: defaultNtfyServer,
 		ntfyTopic: ntfyTopic,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		lastNotify: time.Time{},
 	}
-}
-
-// SendNotification sends a notification to ntfy.sh
-func (s *Service) SendNotification(message string) error {
-	// Rate limiting: prevent notification spam
-	if time.Since(s.lastNotify) < rateLimitSeconds*time.Second {
-		log.Println("Rate limiting: skipping notification")
-		return nil
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
 
-	ntfyURL := fmt.Sprintf("https://ntfy.sh/%s", s.ntfyTopic)
+// SendNotification sends a notification to the ntfy server. Rate limiting and
+// burst coalescing are handled by the Dispatcher in front of it.
+func (s *Service) SendNotification(n monitor.Notification) error {
+	ntfyURL := fmt.Sprintf("%s/%s", s.serverURL, url.PathEscape(s.ntfyTopic))
 
-	req, err := http.NewRequest("POST", ntfyURL, strings.NewReader(message))
+	req, err := http.NewRequest("POST", ntfyURL, strings.NewReader(n.Body()))
 	if err != nil {
-		return fmt.Errorf("failed to create notification request: %w", err)
+		return fmt.Errorf("failed to create notification request: %w", withoutURL(err))
+	}
+
+	// Header values must be ASCII; ntfy decodes RFC 2047 encoded words
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", n.Title()))
+	req.Header.Set("Priority", n.Priority.String())
+	req.Header.Set("Tags", mime.QEncoding.Encode("utf-8", strings.Join(s.tagsFor(n), ",")))
+	if click := n.AppLink(); click != "" {
+		req.Header.Set("Click", click)
+	} else if n.Permalink != "" {
+		req.Header.Set("Click", n.Permalink)
+	}
+	if actions := s.actionsFor(n); actions != "" {
+		req.Header.Set("Actions", actions)
 	}
 
-	req.Header.Set("Title", "Slack Monitor")
-	req.Header.Set("Priority", "default")
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	case s.username != "":
+		req.SetBasicAuth(s.username, s.password)
+	}
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send notification: %w", err)
+		return fmt.Errorf("failed to send notification: %w", withoutURL(err))
 	}
 	defer resp.Body.Close()
 
@@ -60,7 +132,76 @@ func (s *Service) SendNotification(message string) error {
 		return fmt.Errorf("ntfy returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	s.lastNotify = time.Now()
-	log.Printf("Notification sent: %s", message)
+	log.Printf("Notification sent: %s", n)
 	return nil
 }
+
+// tagsFor returns ntfy tags for a notification: an icon for the kind of
+// message, the conversation type, configured tags and the notification's own
+// tags. Tags that match an emoji shortcode are shown as an icon.
+func (s *Service) tagsFor(n monitor.Notification) []string {
+	var tags []string
+	switch {
+	case n.IsThreadReply():
+		tags = append(tags, "thread")
+	case n.IsMention:
+		tags = append(tags, "bell")
+	default:
+		tags = append(tags, "speech_balloon")
+	}
+	if n.ConversationType != "" {
+		tags = append(tags, n.ConversationType)
+	}
+	tags = append(tags, s.tags...)
+	return append(tags, n.Tags...)
+}
+
+// actionsFor builds the ntfy Actions header: an "Open in Slack" button, and a
+// "Mute 1h" button when a mute endpoint is configured
+func (s *Service) actionsFor(n monitor.Notification) string {
+	var actions []string
+	if n.Permalink != "" {
+		actions = append(actions, fmt.Sprintf(`view, "Open in Slack", "%s", clear=true`, n.Permalink))
+	}
+	if s.muteURL != "" && n.ConversationID != "" {
+		muteURL := strings.ReplaceAll(s.muteURL, "{conversation}", url.QueryEscape(n.ConversationID))
+		actions = append(actions, fmt.Sprintf(`http, "Mute 1h", "%s", method=POST, body="conversation=%s&duration=1h", clear=true`, muteURL, n.ConversationID))
+	}
+	return strings.Join(actions, "; ")
+}
+
+// newNtfyTarget creates an ntfy Service from a notification target
+func newNtfyTarget(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	var settings struct {
+		Topic    string   `json:"topic"`
+		Server   string   `json:"server"`
+		Token    string   `json:"token"`
+		Username string   `json:"username"`
+		Password string   `json:"password"`
+		Tags     []string `json:"tags"`
+		MuteURL  string   `json:"mute_url"`
+	}
+	if err := decodeSettings(target, &settings); err != nil {
+		return nil, err
+	}
+	if settings.Topic == "" {
+		return nil, fmt.Errorf("topic is required")
+	}
+	if settings.Token != "" && settings.Username != "" {
+		return nil, fmt.Errorf("token and username are mutually exclusive")
+	}
+	if settings.Server != "" {
+		u, err := url.Parse(settings.Server)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("server must be an absolute http(s) URL, got %q", settings.Server)
+		}
+	}
+
+	return NewService(settings.Topic,
+		WithServer(settings.Server),
+		WithToken(settings.Token),
+		WithBasicAuth(settings.Username, settings.Password),
+		WithTags(settings.Tags...),
+		WithMuteURL(settings.MuteURL),
+	), nil
+}
@@ -1,8 +1,14 @@
 package notification
 
 import (
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
-	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
 )
 
 // TestNewService tests notification service initialization
@@ -21,27 +27,129 @@ func TestNewService(t *testing.T) {
 	}
 }
 
-// TestRateLimiting tests that notification rate limiting works correctly
-func TestRateLimiting(t *testing.T) {
-	notifier := NewService("test-topic")
+// ntfyRequest records a request received by the ntfy stand-in
+type ntfyRequest struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+// newNtfyServer starts an httptest stand-in for an ntfy server
+func newNtfyServer(t *testing.T) (*httptest.Server, chan ntfyRequest) {
+	t.Helper()
+	requests := make(chan ntfyRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- ntfyRequest{path: r.URL.Path, header: r.Header, body: string(body)}
+		w.Write([]byte(`{"id":"abc","event":"message"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// TestSendNotificationHeaders tests the request sent to a self-hosted ntfy server
+func TestSendNotificationHeaders(t *testing.T) {
+	server, requests := newNtfyServer(t)
+	notifier := NewService("alerts",
+		WithServer(server.URL+"/"),
+		WithToken("tk_secret"),
+		WithTags("work"),
+		WithMuteURL("https://home.example.com/mute?c={conversation}"),
+	)
+
+	n := monitor.Notification{
+		Sender:           "Zoë",
+		SenderID:         "U1",
+		ConversationID:   "C1",
+		ConversationType: monitor.ConversationTypePublicChannel,
+		Channel:          "#deploys",
+		TeamID:           "T1",
+		Text:             "v1.2 is live",
+		Timestamp:        "1700000000.000100",
+		Permalink:        "https://acme.slack.com/archives/C1/p1700000000000100",
+		Priority:         monitor.PriorityHigh,
+		IsMention:        true,
+		Mention:          "you",
+		Tags:             []string{"release"},
+	}
+	if err := notifier.SendNotification(n); err != nil {
+		t.Fatalf("SendNotification returned error: %v", err)
+	}
+
+	req := <-requests
+	if req.path != "/alerts" {
+		t.Errorf("Expected path /alerts, got %s", req.path)
+	}
+	if req.body != "v1.2 is live" {
+		t.Errorf("Expected message text as body, got %q", req.body)
+	}
+
+	title, err := new(mime.WordDecoder).DecodeHeader(req.header.Get("Title"))
+	if err != nil || title != "Zoë mentioned you in #deploys" {
+		t.Errorf("Unexpected title %q (%v)", title, err)
+	}
+
+	want := map[string]string{
+		"Authorization": "Bearer tk_secret",
+		"Priority":      "high",
+		"Tags":          "bell,public_channel,work,release",
+		"Click":         "slack://channel?team=T1&id=C1",
+	}
+	for header, value := range want {
+		if got := req.header.Get(header); got != value {
+			t.Errorf("Expected %s header %q, got %q", header, value, got)
+		}
+	}
+
+	actions := req.header.Get("Actions")
+	if !strings.Contains(actions, `view, "Open in Slack", "https://acme.slack.com/archives/C1/p1700000000000100"`) {
+		t.Errorf("Expected Open in Slack action, got %q", actions)
+	}
+	if !strings.Contains(actions, `http, "Mute 1h", "https://home.example.com/mute?c=C1"`) {
+		t.Errorf("Expected Mute 1h action, got %q", actions)
+	}
+}
+
+// TestSendNotificationBasicAuth tests basic auth and the defaults for a minimal notification
+func TestSendNotificationBasicAuth(t *testing.T) {
+	server, requests := newNtfyServer(t)
+	notifier := NewService("alerts", WithServer(server.URL), WithBasicAuth("phil", "pa55"))
 
-	// First notification should be sent (lastNotify is zero time)
-	// Note: We can't actually test sending without mocking HTTP, but we can test the rate limit logic
-	// by checking the time tracking
+	if err := notifier.SendNotification(monitor.Notification{Sender: "Alice", Text: "hi"}); err != nil {
+		t.Fatalf("SendNotification returned error: %v", err)
+	}
 
-	// Simulate notification was just sent
-	notifier.lastNotify = time.Now()
+	req := <-requests
+	if user, pass, ok := (&http.Request{Header: req.header}).BasicAuth(); !ok || user != "phil" || pass != "pa55" {
+		t.Errorf("Expected basic auth phil/pa55, got %q/%q", user, pass)
+	}
+	if got := req.header.Get("Priority"); got != "default" {
+		t.Errorf("Expected default priority, got %q", got)
+	}
+	if got := req.header.Get("Click"); got != "" {
+		t.Errorf("Expected no Click header without a link, got %q", got)
+	}
+	if got := req.header.Get("Actions"); got != "" {
+		t.Errorf("Expected no actions without a link, got %q", got)
+	}
+}
 
-	// Immediate second notification should be skipped (within rate limit)
-	timeSinceLastNotify := time.Since(notifier.lastNotify)
-	if timeSinceLastNotify >= rateLimitSeconds*time.Second {
-		t.Errorf("Test setup error: time since last notify should be less than %d seconds", rateLimitSeconds)
+// TestNtfyTargetSettings tests validation of ntfy target settings
+func TestNtfyTargetSettings(t *testing.T) {
+	notifier, err := New(parseTarget(t, `{"type": "ntfy", "topic": "alerts", "server": "https://ntfy.example.com/", "token": "tk"}`))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if s := notifier.(*Service); s.serverURL != "https://ntfy.example.com" || s.token != "tk" {
+		t.Errorf("Unexpected service settings %+v", s)
 	}
 
-	// After waiting, should be allowed
-	notifier.lastNotify = time.Now().Add(-rateLimitSeconds * time.Second)
-	timeSinceLastNotify = time.Since(notifier.lastNotify)
-	if timeSinceLastNotify < rateLimitSeconds*time.Second {
-		t.Error("Time since last notify should be >= rate limit after waiting")
+	for _, raw := range []string{
+		`{"type": "ntfy", "topic": "alerts", "server": "ntfy.example.com"}`,
+		`{"type": "ntfy", "topic": "alerts", "token": "tk", "username": "phil"}`,
+	} {
+		if _, err := New(parseTarget(t, raw)); err == nil {
+			t.Errorf("Expected error for %s", raw)
+		}
 	}
 }
@@ -0,0 +1,94 @@
+package notification
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// SMTP implements the monitor.Notifier interface by sending email
+type SMTP struct {
+	addr     string // host:port
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error // Replaceable in tests
+}
+
+// newSMTP creates an email notifier from a notification target
+func newSMTP(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	var settings struct {
+		Host     string   `json:"host"`
+		Port     int      `json:"port"`
+		Username string   `json:"username"`
+		Password string   `json:"password"`
+		From     string   `json:"from"`
+		To       []string `json:"to"`
+	}
+	if err := decodeSettings(target, &settings); err != nil {
+		return nil, err
+	}
+	if settings.Host == "" || settings.From == "" || len(settings.To) == 0 {
+		return nil, fmt.Errorf("host, from and to are required")
+	}
+	if settings.Port == 0 {
+		settings.Port = 587
+	}
+
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+
+	return &SMTP{
+		addr:     net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port)),
+		auth:     auth,
+		from:     settings.From,
+		to:       settings.To,
+		sendMail: smtp.SendMail, // Upgrades to STARTTLS when the server offers it
+	}, nil
+}
+
+// SendNotification sends the notification as a plain-text email. Every email
+// about a conversation shares a subject and references the same root
+// Message-ID, so mail clients group them into one thread per conversation.
+func (s *SMTP) SendNotification(n monitor.Notification) error {
+	domain := "slack-monitor.local"
+	if at := strings.LastIndexByte(s.from, '@'); at >= 0 {
+		domain = strings.TrimSuffix(s.from[at+1:], ">")
+	}
+	threadID := fmt.Sprintf("<%s.%s@%s>", n.TeamID, n.ConversationID, domain)
+	messageID := fmt.Sprintf("<%s.%s.%d@%s>", n.ConversationID, strings.Replace(n.Timestamp, ".", "", 1), time.Now().UnixNano(), domain)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Slack: "+n.ConversationName()))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&b, "In-Reply-To: %s\r\n", threadID)
+	fmt.Fprintf(&b, "References: %s\r\n", threadID)
+	if n.Priority >= monitor.PriorityHigh {
+		b.WriteString("X-Priority: 1\r\n")
+		b.WriteString("Importance: high\r\n")
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.String(), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	if n.Permalink != "" {
+		fmt.Fprintf(&b, "\r\n%s\r\n", n.Permalink)
+	}
+
+	if err := s.sendMail(s.addr, s.auth, s.from, s.to, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to send email notification: %w", err)
+	}
+	return nil
+}
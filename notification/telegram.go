@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+const defaultTelegramURL = "https://api.telegram.org"
+
+// Telegram implements the monitor.Notifier interface using a Telegram bot
+type Telegram struct {
+	apiURL     string
+	botToken   string
+	chatID     string
+	httpClient *http.Client
+}
+
+// newTelegram creates a Telegram notifier from a notification target
+func newTelegram(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	var settings struct {
+		BotToken string `json:"bot_token"`
+		ChatID   string `json:"chat_id"`
+		APIURL   string `json:"api_url"`
+	}
+	if err := decodeSettings(target, &settings); err != nil {
+		return nil, err
+	}
+	if settings.BotToken == "" || settings.ChatID == "" {
+		return nil, fmt.Errorf("bot_token and chat_id are required")
+	}
+	if settings.APIURL == "" {
+		settings.APIURL = defaultTelegramURL
+	}
+
+	return &Telegram{
+		apiURL:     strings.TrimSuffix(settings.APIURL, "/"),
+		botToken:   settings.BotToken,
+		chatID:     settings.ChatID,
+		httpClient: newHTTPClient(),
+	}, nil
+}
+
+// SendNotification sends a message to the configured Telegram chat. Low
+// priority notifications are delivered silently.
+func (t *Telegram) SendNotification(n monitor.Notification) error {
+	text := n.String()
+	if n.Permalink != "" {
+		text += "\n" + n.Permalink
+	}
+	body := map[string]interface{}{
+		"chat_id":              t.chatID,
+		"text":                 text,
+		"disable_notification": n.Priority == monitor.PriorityMin || n.Priority == monitor.PriorityLow,
+	}
+	sendURL := fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.botToken)
+	return postJSON(t.httpClient, "POST", sendURL, nil, body, "telegram")
+}
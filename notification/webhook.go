@@ -0,0 +1,83 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// Webhook implements the monitor.Notifier interface by POSTing JSON to a URL
+type Webhook struct {
+	url        string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// newWebhook creates a webhook notifier from a notification target
+func newWebhook(target monitor.NotificationTarget) (monitor.Notifier, error) {
+	var settings struct {
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+	}
+	if err := decodeSettings(target, &settings); err != nil {
+		return nil, err
+	}
+	if settings.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	return &Webhook{
+		url:        settings.URL,
+		headers:    settings.Headers,
+		httpClient: newHTTPClient(),
+	}, nil
+}
+
+// webhookPayload is the JSON body POSTed by the webhook backend
+type webhookPayload struct {
+	Title            string `json:"title"`
+	Text             string `json:"text"`    // One-line summary, e.g. "DM from Alice: hi"
+	Message          string `json:"message"` // Message text only
+	Sender           string `json:"sender"`
+	SenderID         string `json:"sender_id"`
+	Channel          string `json:"channel,omitempty"`
+	ConversationID   string `json:"conversation_id"`
+	ConversationType string `json:"conversation_type,omitempty"`
+	TeamID           string `json:"team_id,omitempty"`
+	TS               string `json:"ts"`
+	ThreadTS         string `json:"thread_ts,omitempty"`
+	Permalink        string `json:"permalink,omitempty"`
+	Priority         string `json:"priority"`
+	IsMention        bool   `json:"is_mention"`
+	Mention          string `json:"mention,omitempty"`
+	Timestamp        string `json:"timestamp"` // RFC 3339 time the message was posted
+}
+
+// SendNotification POSTs the notification as JSON
+func (w *Webhook) SendNotification(n monitor.Notification) error {
+	posted := time.Now()
+	if n.Timestamp != "" {
+		posted = n.Time()
+	}
+	body := webhookPayload{
+		Title:            n.Title(),
+		Text:             n.String(),
+		Message:          n.Text,
+		Sender:           n.Sender,
+		SenderID:         n.SenderID,
+		Channel:          n.Channel,
+		ConversationID:   n.ConversationID,
+		ConversationType: n.ConversationType,
+		TeamID:           n.TeamID,
+		TS:               n.Timestamp,
+		ThreadTS:         n.ThreadTS,
+		Permalink:        n.Permalink,
+		Priority:         n.Priority.String(),
+		IsMention:        n.IsMention,
+		Mention:          n.Mention,
+		Timestamp:        posted.UTC().Format(time.RFC3339),
+	}
+	return postJSON(w.httpClient, "POST", w.url, w.headers, body, "webhook")
+}
@@ -0,0 +1,197 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRealtimePollSecs = 600             // Full check interval while the real-time connection is up
+	realtimeMinBackoff      = 1 * time.Second // First reconnect delay
+	realtimeMaxBackoff      = 5 * time.Minute // Upper bound for reconnect delays
+	realtimeStableAfter     = 1 * time.Minute // A connection up this long resets the backoff
+)
+
+// realtimeStream carries events from the real-time connection to the Run loop
+type realtimeStream struct {
+	events    chan MessageEvent
+	connected chan bool // Each change of connection state
+	up        bool      // Whether the connection is currently up, as seen by Run
+}
+
+// startRealtime starts streaming real-time events if enabled and supported by
+// the Slack client. Without it the stream's channels are nil and never ready,
+// so Run simply polls.
+func (m *Monitor) startRealtime(ctx context.Context) *realtimeStream {
+	stream := &realtimeStream{}
+	client, ok := m.slackClient.(RealtimeClient)
+	if !m.config.Monitor.Realtime || !ok {
+		return stream
+	}
+	stream.events = make(chan MessageEvent, 100)
+	stream.connected = make(chan bool)
+	go m.streamRealtime(ctx, client, stream)
+	return stream
+}
+
+// realtimePollInterval returns how often a full check runs while connected
+func (c *Config) realtimePollInterval() time.Duration {
+	return time.Duration(orDefault(c.Monitor.RealtimePollSecs, defaultRealtimePollSecs)) * time.Second
+}
+
+// streamRealtime keeps a real-time connection open until ctx is cancelled,
+// reconnecting with exponential backoff, and forwards its message events.
+// Connection state changes are reported on stream.connected.
+func (m *Monitor) streamRealtime(ctx context.Context, client RealtimeClient, stream *realtimeStream) {
+	backoff := realtimeMinBackoff
+	up := false
+	setUp := func(connected bool) {
+		if connected == up {
+			return
+		}
+		up = connected
+		select {
+		case stream.connected <- connected:
+		case <-ctx.Done():
+		}
+	}
+
+	for {
+		conn, err := client.ConnectRealtime(ctx)
+		if ctx.Err() != nil {
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if err != nil {
+			setUp(false)
+			log.Printf("Real-time connection failed, polling until it is back (retrying in %s): %v", backoff, err)
+		} else {
+			log.Println("Real-time connection established")
+			setUp(true)
+			connectedAt := time.Now()
+			err = forwardEvents(ctx, conn, stream.events)
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(connectedAt) >= realtimeStableAfter {
+				backoff = realtimeMinBackoff
+			}
+			setUp(false)
+			log.Printf("Real-time connection lost, polling until it is back (reconnecting in %s): %v", backoff, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > realtimeMaxBackoff {
+			backoff = realtimeMaxBackoff
+		}
+	}
+}
+
+// forwardEvents sends conn's events to events until the connection fails or
+// ctx is cancelled
+func forwardEvents(ctx context.Context, conn RealtimeConnection, events chan<- MessageEvent) error {
+	// NextEvent doesn't take a context; closing the connection unblocks it
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		event, err := conn.NextEvent()
+		if err != nil {
+			return err
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitForNextCycle waits interval before the next full check, checking
+// conversations as real-time events arrive. It returns early when the
+// real-time connection comes up or goes down, so messages missed in between
+// are caught up and the interval recomputed, or when an event arrives for a
+// conversation the last full check didn't know, or when the configuration is
+// reloaded. Meanwhile, notifications held for quiet hours are released once
+// they end. It returns false once ctx is cancelled.
+func (m *Monitor) waitForNextCycle(ctx context.Context, interval time.Duration, stream *realtimeStream, state *State) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	held := time.NewTicker(m.heldCheckInterval)
+	defer held.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return true
+		case <-held.C:
+			m.releaseHeld(state)
+		case up := <-stream.connected:
+			stream.up = up
+			return true
+		case pending := <-m.reloads:
+			if err := m.applyConfig(ctx, pending); err != nil {
+				log.Printf("Reloaded configuration rejected, keeping the current one: %v", err)
+				continue
+			}
+			return true
+		case event := <-stream.events:
+			if m.handleEvent(ctx, event, state) {
+				return true
+			}
+		}
+	}
+}
+
+// handleEvent checks the conversation a real-time message was posted in,
+// through the same path as polling, and saves state. It returns true if the
+// message is in a DM the last full check didn't know (a new conversation), so
+// a full check should run now; other unknown conversations aren't monitored
+// and are ignored. The check gets the same time budget as a full cycle.
+func (m *Monitor) handleEvent(ctx context.Context, event MessageEvent, state *State) bool {
+	if event.User == m.userID {
+		return false
+	}
+	conv, ok := m.monitored[event.ChannelID]
+	if !ok {
+		if strings.HasPrefix(event.ChannelID, "D") && !m.unknownDMs[event.ChannelID] {
+			m.unknownDMs[event.ChannelID] = true
+			return true
+		}
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.cycleTimeout())
+	defer cancel()
+	now := time.Now()
+	err := m.checkConversation(ctx, conv, state)
+	if err != nil {
+		log.Printf("Failed to check %s after real-time event: %v", conv.ID, err)
+	}
+	if err == nil || ctx.Err() == nil {
+		m.lastPolled[conv.ID] = now
+		m.lastFetched[conv.ID] = now
+	}
+	if err := m.stateStore.Save(state); err != nil {
+		log.Printf("Failed to save state: %v", err)
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// pendingConfig is a reloaded configuration waiting to be applied
+type pendingConfig struct {
+	config *Config
+	apply  func() // Switches settings kept outside the monitor (optional)
+}
+
+// Reload replaces the configuration of a running monitor with a new, already
+// validated one. It takes effect between check cycles, so no cycle sees a mix
+// of old and new settings, and a check runs straight away with the new
+// settings. apply, if not nil, runs at the same moment on Run's goroutine, to
+// switch settings the monitor doesn't own, such as the notification targets
+// its rules route to. Changed Slack tokens are checked with Slack first; if
+// they don't authenticate, the whole reload is rejected and the current
+// configuration kept. Whether to use the real-time connection is only read at startup.
+func (m *Monitor) Reload(config *Config, apply func()) {
+	pending := pendingConfig{config: config, apply: apply}
+	for {
+		select {
+		case m.reloads <- pending:
+			return
+		default:
+		}
+		// A newer configuration supersedes one not yet applied
+		select {
+		case <-m.reloads:
+		default:
+		}
+	}
+}
+
+// applyConfig switches to a reloaded configuration. It runs on Run's goroutine
+// while no check is in progress. Changed Slack tokens are authenticated before
+// anything is switched; if that fails, nothing is and the error is returned.
+func (m *Monitor) applyConfig(ctx context.Context, pending pendingConfig) error {
+	config := pending.config
+	if config.Slack.XoxcToken != m.config.Slack.XoxcToken || config.Slack.XoxdToken != m.config.Slack.XoxdToken {
+		if err := m.switchCredentials(ctx, config.Slack.XoxcToken, config.Slack.XoxdToken); err != nil {
+			return fmt.Errorf("new Slack tokens: %w", err)
+		}
+		log.Println("Using new Slack tokens")
+	}
+	if pending.apply != nil {
+		pending.apply()
+	}
+	m.config = config
+	log.Println("Configuration reloaded")
+	return nil
+}
+
+// switchCredentials moves the Slack client to new tokens and checks them with
+// Slack, refreshing the identity the monitor acts as, since the tokens may
+// belong to another user or workspace. If they don't authenticate, the client
+// goes back to the configured tokens.
+func (m *Monitor) switchCredentials(ctx context.Context, xoxcToken, xoxdToken string) error {
+	m.slackClient.SetCredentials(xoxcToken, xoxdToken)
+	userID, err := m.slackClient.TestAuth(ctx)
+	if err != nil {
+		m.slackClient.SetCredentials(m.config.Slack.XoxcToken, m.config.Slack.XoxdToken)
+		return err
+	}
+	m.userID = userID
+	m.userGroupsLoaded = time.Time{} // Group membership is reloaded for the new user
+	return nil
+}
@@ -0,0 +1,312 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Rule actions
+const (
+	RuleActionNotify = "notify" // Send the notification (the default)
+	RuleActionDrop   = "drop"   // Discard the message
+	RuleActionDigest = "digest" // Hold the message for the next digest summary
+)
+
+// Rule routes the messages it matches. Rules are evaluated in order and the
+// first match wins; messages no rule matches get the default behaviour (DMs,
+// group DMs, mentions and watched thread replies notify, other channel
+// messages are ignored).
+type Rule struct {
+	Name     string    `json:"name"`     // Optional label for logs and dry runs
+	Match    RuleMatch `json:"match"`    // Conditions; an empty match matches every message
+	Action   string    `json:"action"`   // One of the RuleAction* constants (default notify)
+	Priority string    `json:"priority"` // Override priority ("min", "low", "default", "high", "urgent")
+	Targets  []string  `json:"targets"`  // Only deliver to these notification targets (by name)
+	Tags     []string  `json:"tags"`     // Extra tags added to the notification
+}
+
+// RuleMatch lists a rule's conditions. Every condition that is set must hold;
+// a list condition holds if any of its entries matches.
+type RuleMatch struct {
+	Senders           []string   `json:"senders"`            // User IDs or display names
+	Conversations     []string   `json:"conversations"`      // Channel IDs, channel names (with or without "#") or DM user IDs
+	ConversationTypes []string   `json:"conversation_types"` // ConversationType* values
+	Keywords          []string   `json:"keywords"`           // Case-insensitive substrings of the message text
+	Regex             *Pattern   `json:"regex"`              // Regular expression matched against the message text
+	Mention           *bool      `json:"mention"`            // Whether the message must (or must not) mention the user
+	Thread            *bool      `json:"thread"`             // Whether the message must (or must not) be a thread reply
+	Hours             *TimeRange `json:"hours"`              // Local time of day, e.g. "09:00-17:30" (may wrap midnight)
+	Days              []string   `json:"days"`               // Local weekdays ("mon", "tue", ...)
+}
+
+// Pattern is a regular expression decoded from a JSON string
+type Pattern struct {
+	*regexp.Regexp
+}
+
+// UnmarshalText compiles the expression
+func (p *Pattern) UnmarshalText(text []byte) error {
+	re, err := regexp.Compile(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+	p.Regexp = re
+	return nil
+}
+
+// TimeRange is a daily time window such as "22:00-07:00", decoded from a JSON
+// string. The start is inclusive and the end exclusive; a window whose end is
+// before its start wraps past midnight.
+type TimeRange struct {
+	Start int // Minutes after midnight
+	End   int // Minutes after midnight
+}
+
+// ParseTimeRange parses a "HH:MM-HH:MM" window
+func ParseTimeRange(s string) (TimeRange, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return TimeRange{}, fmt.Errorf("invalid time range %q (want HH:MM-HH:MM)", s)
+	}
+	var r TimeRange
+	var err error
+	if r.Start, err = parseClock(start); err != nil {
+		return TimeRange{}, fmt.Errorf("invalid time range %q: %w", s, err)
+	}
+	if r.End, err = parseClock(end); err != nil {
+		return TimeRange{}, fmt.Errorf("invalid time range %q: %w", s, err)
+	}
+	return r, nil
+}
+
+// UnmarshalText parses a "HH:MM-HH:MM" window
+func (r *TimeRange) UnmarshalText(text []byte) error {
+	parsed, err := ParseTimeRange(string(text))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// String returns the window in "HH:MM-HH:MM" form
+func (r TimeRange) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", r.Start/60, r.Start%60, r.End/60, r.End%60)
+}
+
+// Contains reports whether t's time of day falls in the window. A window that
+// starts and ends at the same time covers the whole day.
+func (r TimeRange) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	switch {
+	case r.Start == r.End:
+		return true
+	case r.Start < r.End:
+		return minute >= r.Start && minute < r.End
+	}
+	return minute >= r.Start || minute < r.End
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", strings.TrimSpace(s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// weekdays maps the accepted day names to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWeekday parses a day name such as "mon" or "Monday"
+func ParseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if len(name) >= 3 {
+		if day, ok := weekdays[name[:3]]; ok && strings.HasPrefix(strings.ToLower(day.String()), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q (valid: mon, tue, wed, thu, fri, sat, sun)", s)
+}
+
+// ParsePriority parses a priority name as returned by Priority.String
+func ParsePriority(s string) (Priority, error) {
+	for p := PriorityMin; p <= PriorityUrgent; p++ {
+		if strings.EqualFold(s, p.String()) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q (valid: min, low, default, high, urgent)", s)
+}
+
+// Validate checks the fields that are not validated while decoding
+func (r Rule) Validate() error {
+	switch r.Action {
+	case "", RuleActionNotify, RuleActionDrop, RuleActionDigest:
+	default:
+		return fmt.Errorf("unknown action %q (valid: notify, drop, digest)", r.Action)
+	}
+	if r.Priority != "" {
+		if _, err := ParsePriority(r.Priority); err != nil {
+			return err
+		}
+	}
+	for _, t := range r.Match.ConversationTypes {
+		switch t {
+		case ConversationTypeIM, ConversationTypeMPIM, ConversationTypePrivateChannel, ConversationTypePublicChannel:
+		default:
+			return fmt.Errorf("unknown conversation type %q", t)
+		}
+	}
+	for _, day := range r.Match.Days {
+		if _, err := ParseWeekday(day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisplayName returns the rule's name, or its 1-based position if unnamed
+func (r Rule) DisplayName(index int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// ActionName returns the rule's action, defaulting to notify
+func (r Rule) ActionName() string {
+	if r.Action == "" {
+		return RuleActionNotify
+	}
+	return r.Action
+}
+
+// Matches reports whether every condition of the rule holds for n
+func (r Rule) Matches(n Notification) bool {
+	match := r.Match
+	if len(match.Senders) > 0 && !matchesSender(n, match.Senders) {
+		return false
+	}
+	if len(match.Conversations) > 0 && !matchesConversation(n, match.Conversations) {
+		return false
+	}
+	if len(match.ConversationTypes) > 0 && !containsString(match.ConversationTypes, n.ConversationType) {
+		return false
+	}
+	if len(match.Keywords) > 0 && !containsKeyword(n.Text, match.Keywords) {
+		return false
+	}
+	if match.Regex != nil && match.Regex.Regexp != nil && !match.Regex.MatchString(n.Text) {
+		return false
+	}
+	if match.Mention != nil && n.IsMention != *match.Mention {
+		return false
+	}
+	if match.Thread != nil && n.IsThreadReply() != *match.Thread {
+		return false
+	}
+	if match.Hours != nil && !match.Hours.Contains(n.Time()) {
+		return false
+	}
+	if len(match.Days) > 0 && !matchesDay(n.Time(), match.Days) {
+		return false
+	}
+	return true
+}
+
+// Apply returns n with the rule's priority, targets and tags applied
+func (r Rule) Apply(n Notification) Notification {
+	if p, err := ParsePriority(r.Priority); err == nil {
+		n.Priority = p
+	}
+	if len(r.Targets) > 0 {
+		n.Targets = append([]string(nil), r.Targets...)
+	}
+	if len(r.Tags) > 0 {
+		n.Tags = append(append([]string(nil), n.Tags...), r.Tags...)
+	}
+	return n
+}
+
+// MatchRule returns the index of the first rule matching n, or -1 if none does
+func MatchRule(rules []Rule, n Notification) int {
+	for i, rule := range rules {
+		if rule.Matches(n) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchesSender reports whether n was sent by any of the given users
+func matchesSender(n Notification, entries []string) bool {
+	for _, entry := range entries {
+		entry = strings.TrimPrefix(strings.TrimSpace(entry), "@")
+		if entry == "" {
+			continue
+		}
+		if entry == n.SenderID || (n.Sender != "" && strings.EqualFold(entry, n.Sender)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesConversation reports whether n was posted in any of the given
+// conversations, by channel ID, channel name or (for DMs) the other user's ID
+func matchesConversation(n Notification, entries []string) bool {
+	name := strings.TrimPrefix(n.Channel, "#")
+	for _, entry := range entries {
+		entry = strings.TrimPrefix(strings.TrimSpace(entry), "#")
+		if entry == "" {
+			continue
+		}
+		if entry == n.ConversationID || (name != "" && strings.EqualFold(entry, name)) || (n.IsDM() && entry == n.SenderID) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeyword reports whether text contains any keyword, ignoring case
+func containsKeyword(text string, keywords []string) bool {
+	text = strings.ToLower(text)
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDay reports whether t falls on any of the given days
+func matchesDay(t time.Time, days []string) bool {
+	for _, name := range days {
+		if day, err := ParseWeekday(name); err == nil && day == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// Schedule describes when notifications are held back. During quiet hours and
+// holidays, notifications are kept in State and sent as one summary when the
+// quiet period ends; messages from VIPs and urgent notifications still go out.
+type Schedule struct {
+	Timezone   *Location     `json:"timezone"`    // IANA zone for windows and holidays (default: local time)
+	QuietHours []QuietWindow `json:"quiet_hours"` // Weekly quiet windows
+	Holidays   []string      `json:"holidays"`    // Whole quiet days, "2006-01-02"
+	VIPs       []string      `json:"vips"`        // User IDs or display names that always break through
+}
+
+// QuietWindow is a weekly quiet period. A window that wraps midnight belongs to
+// the day it starts on, so {"days": ["fri"], "hours": "22:00-08:00"} covers
+// Friday night until Saturday morning.
+type QuietWindow struct {
+	Days  []string   `json:"days"`  // Weekdays ("mon", "tue", ...); empty means every day
+	Hours *TimeRange `json:"hours"` // Time of day; empty means the whole day
+}
+
+// Location is a time zone decoded from its IANA name (e.g. "Europe/Berlin")
+type Location struct {
+	*time.Location
+}
+
+// UnmarshalText loads the named time zone
+func (l *Location) UnmarshalText(text []byte) error {
+	loc, err := time.LoadLocation(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	l.Location = loc
+	return nil
+}
+
+// Validate checks the weekday names and holiday dates
+func (s Schedule) Validate() error {
+	for i, window := range s.QuietHours {
+		for _, day := range window.Days {
+			if _, err := ParseWeekday(day); err != nil {
+				return fmt.Errorf("quiet_hours[%d]: %w", i, err)
+			}
+		}
+	}
+	for _, date := range s.Holidays {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("holidays: %q is not a YYYY-MM-DD date", date)
+		}
+	}
+	return nil
+}
+
+// location returns the schedule's time zone
+func (s Schedule) location() *time.Location {
+	if s.Timezone != nil && s.Timezone.Location != nil {
+		return s.Timezone.Location
+	}
+	return time.Local
+}
+
+// IsQuiet reports whether t falls in a quiet window or on a holiday
+func (s Schedule) IsQuiet(t time.Time) bool {
+	t = t.In(s.location())
+	date := t.Format("2006-01-02")
+	for _, holiday := range s.Holidays {
+		if holiday == date {
+			return true
+		}
+	}
+
+	yesterday := t.AddDate(0, 0, -1)
+	for _, window := range s.QuietHours {
+		if window.Hours == nil {
+			if window.onDay(t) {
+				return true
+			}
+			continue
+		}
+		r := *window.Hours
+		minute := t.Hour()*60 + t.Minute()
+		switch {
+		case r.Start == r.End:
+			if window.onDay(t) {
+				return true
+			}
+		case r.Start < r.End:
+			if window.onDay(t) && minute >= r.Start && minute < r.End {
+				return true
+			}
+		default:
+			// Wraps midnight: the evening part belongs to today, the morning part to yesterday
+			if (window.onDay(t) && minute >= r.Start) || (window.onDay(yesterday) && minute < r.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsVIP reports whether n was sent by a VIP
+func (s Schedule) IsVIP(n Notification) bool {
+	return len(s.VIPs) > 0 && matchesSender(n, s.VIPs)
+}
+
+// Holds reports whether n should be held at time now: it is quiet, and n is
+// neither from a VIP nor urgent
+func (s Schedule) Holds(n Notification, now time.Time) bool {
+	return n.Priority < PriorityUrgent && !s.IsVIP(n) && s.IsQuiet(now)
+}
+
+// onDay reports whether the window applies on t's weekday
+func (w QuietWindow) onDay(t time.Time) bool {
+	return len(w.Days) == 0 || matchesDay(t, w.Days)
+}
+
+// heldCheckInterval is how often held notifications are checked for release
+// between check cycles; quiet hours end on the minute
+const heldCheckInterval = time.Minute
+
+// releaseHeld sends notifications held during quiet hours once they are over,
+// between check cycles, so their release doesn't wait for the next full check
+// (up to the cold tier or real-time poll interval). State is saved if any were sent.
+func (m *Monitor) releaseHeld(state *State) {
+	held := len(state.Held)
+	if held == 0 {
+		return
+	}
+	m.flushHeld(state)
+	if len(state.Held) == held {
+		return
+	}
+	if err := m.stateStore.Save(state); err != nil {
+		log.Printf("Failed to save state: %v", err)
+	}
+}
+
+// flushHeld sends the notifications held during quiet hours once the quiet
+// period is over, as one summary per set of targets they were routed to. Any
+// that fail to send are kept for the next cycle.
+func (m *Monitor) flushHeld(state *State) {
+	if len(state.Held) == 0 || m.config.Schedule.IsQuiet(time.Now()) {
+		return
+	}
+
+	var kept []Notification
+	sent := 0
+	for _, group := range GroupByTargets(state.Held) {
+		summary := Summarize(group)
+		summary.ID = "held/" + group[len(group)-1].DeliveryID()
+		if err := m.notifier.SendNotification(summary); err != nil {
+			log.Printf("Failed to send %d notification(s) held during quiet hours: %v", len(group), err)
+			kept = append(kept, group...)
+			continue
+		}
+		sent += len(group)
+	}
+	if sent > 0 {
+		log.Printf("Quiet hours over, sent %d held notification(s)", sent)
+	}
+	// Keep what is left oldest first
+	sort.SliceStable(kept, func(i, j int) bool {
+		return compareTimestamps(kept[i].Timestamp, kept[j].Timestamp) < 0
+	})
+	state.Held = kept
+}
@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrSessionExpired is wrapped by SlackClient errors when Slack rejects the
+// session's tokens, e.g. because the browser session expired or was signed out
+var ErrSessionExpired = errors.New("Slack session expired")
+
+// CredentialSource provides the Slack tokens currently configured. While the
+// session is expired, the monitor checks it for new tokens to resume with.
+type CredentialSource interface {
+	SlackCredentials() (xoxcToken, xoxdToken string, err error)
+}
+
+// SetCredentialSource sets where to look for new tokens once the session
+// expires. Without one, the monitor waits until it is restarted.
+func (m *Monitor) SetCredentialSource(source CredentialSource) {
+	m.credentials = source
+}
+
+// awaitNewSession handles an expired session: it sends a single notification,
+// then pauses polling, checking the credential source every poll interval, and
+// any reloaded configuration, for new tokens. It returns true once new tokens
+// authenticate, or false if ctx is cancelled first.
+func (m *Monitor) awaitNewSession(ctx context.Context, cause error) bool {
+	log.Printf("Slack session expired (%v); monitoring paused until new tokens are configured", cause)
+	m.notifySessionExpired(cause)
+
+	tried := [2]string{m.config.Slack.XoxcToken, m.config.Slack.XoxdToken}
+	for {
+		var xoxc, xoxd string
+		var err error
+		select {
+		case <-ctx.Done():
+			return false
+		case pending := <-m.reloads:
+			// Changed tokens are checked as the configuration is applied
+			xoxc, xoxd = pending.config.Slack.XoxcToken, pending.config.Slack.XoxdToken
+			if [2]string{xoxc, xoxd} == tried {
+				if err := m.applyConfig(ctx, pending); err != nil {
+					log.Printf("Reloaded configuration rejected, keeping the current one: %v", err)
+				}
+				continue
+			}
+			err = m.applyConfig(ctx, pending)
+		case <-time.After(m.config.pollInterval()):
+			if m.credentials == nil {
+				continue
+			}
+			xoxc, xoxd, err = m.credentials.SlackCredentials()
+			if err != nil {
+				log.Printf("Failed to read Slack credentials: %v", err)
+				continue
+			}
+			if [2]string{xoxc, xoxd} == tried {
+				continue
+			}
+			if err = m.switchCredentials(ctx, xoxc, xoxd); err == nil {
+				renewed := *m.config
+				renewed.Slack.XoxcToken, renewed.Slack.XoxdToken = xoxc, xoxd
+				m.config = &renewed
+			}
+		}
+
+		if errors.Is(err, ErrSessionExpired) {
+			// Only wait for different tokens once Slack has rejected these
+			tried = [2]string{xoxc, xoxd}
+			log.Printf("New Slack tokens were rejected, still paused: %v", err)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to check new Slack tokens, retrying next interval: %v", err)
+			continue
+		}
+		log.Println("Slack session renewed, resuming monitoring")
+		return true
+	}
+}
+
+// notifySessionExpired tells the user the session has expired. It goes straight
+// to the notifier, bypassing rules and quiet hours, since nothing else will be
+// delivered until the user acts.
+func (m *Monitor) notifySessionExpired(cause error) {
+	now := formatTimestamp(time.Now())
+	n := Notification{
+		ID:        "session-expired/" + now,
+		Subject:   "Slack session expired",
+		Sender:    "Slack Monitor",
+		Text:      "Slack rejected the monitor's session (" + cause.Error() + "). Messages are not being checked; update xoxc_token and xoxd_token in the config file to resume.",
+		Timestamp: now,
+		Priority:  PriorityHigh,
+	}
+	if err := m.notifier.SendNotification(n); err != nil {
+		log.Printf("Failed to send session expiry notification: %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package slack
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,42 +10,87 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/FourPalms/golang-slack-monitor"
 )
 
 const (
+	defaultBaseURL    = "https://slack.com/api/"
 	conversationLimit = 500 // Max conversations to fetch per API call
 	messageLimit      = 100 // Max messages to fetch per API call
+	defaultMaxPages   = 10  // Max pages to follow per paginated call
 )
 
 // Client implements the monitor.SlackClient interface
 type Client struct {
+	credMu              sync.RWMutex // Guards the tokens, which SetCredentials may replace at any time
 	xoxcToken           string
 	xoxdToken           string
+	baseURL             string // Web API root, always ending in "/"
 	httpClient          *http.Client
-	authenticatedUserID string // ID of the authenticated user (to filter own messages)
+	transport           http.RoundTripper // Custom transport; overrides proxy and TLS settings
+	proxyURL            *url.URL
+	tlsConfig           *tls.Config
+	maxPages            int // Max cursor pages to follow per paginated call
+	maxRetries          int // Max retries after a 429 or 5xx response
+	limiter             *rateLimiter
+	counters            rateLimitCounters
+	sleep               func(context.Context, time.Duration) error // Replaceable in tests
+	authenticatedUserID string                                     // ID of the authenticated user (to filter own messages)
+	teamID              string                                     // ID of the authenticated workspace
+	workspaceURL        string                                     // Workspace web URL, e.g. "https://acme.slack.com/"
 }
 
 // NewClient creates a new Slack API client
-func NewClient(xoxcToken, xoxdToken string) *Client {
-	return &Client{
+func NewClient(xoxcToken, xoxdToken string, opts ...Option) *Client {
+	c := &Client{
 		xoxcToken: xoxcToken,
 		xoxdToken: xoxdToken,
+		baseURL:   defaultBaseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxPages:   defaultMaxPages,
+		maxRetries: defaultMaxRetries,
+		limiter:    newRateLimiter(),
+		sleep:      sleepContext,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient.Transport = c.buildTransport()
+	return c
+}
+
+// buildTransport returns the custom transport if one was given, otherwise a copy of
+// the default transport with any proxy and TLS settings applied
+func (c *Client) buildTransport() http.RoundTripper {
+	if c.transport != nil {
+		return c.transport
+	}
+	if c.proxyURL == nil && c.tlsConfig == nil {
+		return http.DefaultTransport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(c.proxyURL)
+	}
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig
+	}
+	return transport
 }
 
 // TestAuth validates the authentication tokens and returns the authenticated user ID
-func (c *Client) TestAuth() (string, error) {
+func (c *Client) TestAuth(ctx context.Context) (string, error) {
 	// slack-go uses POST with token as a parameter for auth.test
 	params := url.Values{
-		"token": {c.xoxcToken},
+		"token": {c.token()},
 	}
-	body, err := c.makeRequest("POST", "auth.test", params)
+	body, err := c.makeRequest(ctx, "POST", "auth.test", params)
 	if err != nil {
 		return "", err
 	}
@@ -54,94 +101,247 @@ func (c *Client) TestAuth() (string, error) {
 	}
 
 	if !response.OK {
-		return "", fmt.Errorf("Slack authentication failed: %s", response.Error)
+		return "", fmt.Errorf("Slack authentication failed: %w", &APIError{Method: "auth.test", Code: response.Error})
 	}
 
 	log.Printf("Authenticated as %s (%s) in workspace %s", response.User, response.UserID, response.Team)
 	c.authenticatedUserID = response.UserID
+	c.teamID = response.TeamID
+	c.workspaceURL = response.URL
 	return response.UserID, nil
 }
 
-// GetDMConversations fetches all DM conversations
-func (c *Client) GetDMConversations() ([]monitor.Conversation, error) {
-	params := url.Values{}
-	params.Set("types", "im")
-	params.Set("exclude_archived", "true")
-	params.Set("limit", fmt.Sprintf("%d", conversationLimit))
-	params.Set("token", c.xoxcToken) // GET requests need token as query parameter
+// GetDMConversations fetches all DM conversations, following pagination cursors
+func (c *Client) GetDMConversations(ctx context.Context) ([]monitor.Conversation, error) {
+	return c.GetConversations(ctx, []string{monitor.ConversationTypeIM})
+}
 
-	body, err := c.makeRequest("GET", "conversations.list", params)
-	if err != nil {
-		return nil, err
-	}
+// GetConversations fetches all conversations of the given types, following pagination cursors
+func (c *Client) GetConversations(ctx context.Context, types []string) ([]monitor.Conversation, error) {
+	var conversations []monitor.Conversation
+	cursor := ""
+
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("types", strings.Join(types, ","))
+		params.Set("exclude_archived", "true")
+		params.Set("limit", fmt.Sprintf("%d", conversationLimit))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		params.Set("token", c.token()) // GET requests need token as query parameter
 
-	var response conversationsListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse conversations response: %w", err)
-	}
+		body, err := c.makeRequest(ctx, "GET", "conversations.list", params)
+		if err != nil {
+			return nil, err
+		}
 
-	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
-	}
+		var response conversationsListResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse conversations response: %w", err)
+		}
 
-	// Convert API response to domain types
-	conversations := make([]monitor.Conversation, len(response.Channels))
-	for i, ch := range response.Channels {
-		conversations[i] = monitor.Conversation{
-			ID:            ch.ID,
-			User:          ch.User,
-			IsUserDeleted: ch.IsUserDeleted,
+		if !response.OK {
+			return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "conversations.list", Code: response.Error})
+		}
+
+		// Convert API response to domain types
+		for _, ch := range response.Channels {
+			conversations = append(conversations, monitor.Conversation{
+				ID:            ch.ID,
+				Type:          ch.conversationType(),
+				Name:          ch.Name,
+				User:          ch.User,
+				IsUserDeleted: ch.IsUserDeleted,
+				IsMember:      ch.IsMember || ch.IsIM || ch.IsMPIM,
+			})
+		}
+
+		cursor = response.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+		if page >= c.maxPages {
+			log.Printf("Warning: conversations.list has more results after %d page(s), stopping at page cap", page)
+			break
 		}
 	}
 
 	return conversations, nil
 }
 
-// GetConversationHistory fetches messages from a conversation since a given timestamp
-func (c *Client) GetConversationHistory(channelID, oldestTS string) ([]monitor.Message, error) {
+// GetConversationInfo fetches information about a single conversation
+func (c *Client) GetConversationInfo(ctx context.Context, channelID string) (*monitor.Conversation, error) {
 	params := url.Values{}
 	params.Set("channel", channelID)
-	if oldestTS != "" {
-		params.Set("oldest", oldestTS)
-	}
-	params.Set("limit", fmt.Sprintf("%d", messageLimit))
-	params.Set("token", c.xoxcToken) // GET requests need token as query parameter
+	params.Set("token", c.token()) // GET requests need token as query parameter
 
-	body, err := c.makeRequest("GET", "conversations.history", params)
+	body, err := c.makeRequest(ctx, "GET", "conversations.info", params)
 	if err != nil {
 		return nil, err
 	}
 
-	var response conversationsHistoryResponse
+	var response conversationsInfoResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse history response: %w", err)
+		return nil, fmt.Errorf("failed to parse conversation response: %w", err)
 	}
 
 	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
+		return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "conversations.info", Code: response.Error})
 	}
 
-	// Convert API response to domain types
-	messages := make([]monitor.Message, len(response.Messages))
-	for i, msg := range response.Messages {
-		messages[i] = monitor.Message{
-			Timestamp: msg.Timestamp,
-			User:      msg.User,
-			Text:      msg.Text,
-			Type:      msg.Type,
+	// Convert API response to domain type
+	ch := response.Channel
+	return &monitor.Conversation{
+		ID:            ch.ID,
+		Type:          ch.conversationType(),
+		Name:          ch.Name,
+		User:          ch.User,
+		IsUserDeleted: ch.IsUserDeleted,
+		IsMember:      ch.IsMember || ch.IsIM || ch.IsMPIM,
+	}, nil
+}
+
+// conversationType maps the API's boolean flags onto a conversations.list type
+func (c conversationResponse) conversationType() string {
+	switch {
+	case c.IsIM:
+		return monitor.ConversationTypeIM
+	case c.IsMPIM:
+		return monitor.ConversationTypeMPIM
+	case c.IsPrivate:
+		return monitor.ConversationTypePrivateChannel
+	default:
+		return monitor.ConversationTypePublicChannel
+	}
+}
+
+// GetConversationHistory fetches messages from a conversation since a given timestamp,
+// following pagination cursors. Messages are returned newest first. If the page
+// cap is reached first, the newest messages are returned with an error wrapping
+// monitor.ErrHistoryTruncated.
+func (c *Client) GetConversationHistory(ctx context.Context, channelID, oldestTS string) ([]monitor.Message, error) {
+	var messages []monitor.Message
+	cursor := ""
+
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("channel", channelID)
+		if oldestTS != "" {
+			params.Set("oldest", oldestTS)
+		}
+		params.Set("limit", fmt.Sprintf("%d", messageLimit))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		params.Set("token", c.token()) // GET requests need token as query parameter
+
+		body, err := c.makeRequest(ctx, "GET", "conversations.history", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var response conversationsHistoryResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse history response: %w", err)
+		}
+
+		if !response.OK {
+			return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "conversations.history", Code: response.Error})
+		}
+
+		// Convert API response to domain types
+		for _, msg := range response.Messages {
+			messages = append(messages, msg.toMessage())
+		}
+
+		cursor = response.ResponseMetadata.NextCursor
+		if !response.HasMore || cursor == "" {
+			break
+		}
+		if page >= c.maxPages {
+			return messages, fmt.Errorf("conversations.history for %s has more results after %d page(s): %w", channelID, page, monitor.ErrHistoryTruncated)
 		}
 	}
 
 	return messages, nil
 }
 
+// GetThreadReplies fetches replies in a thread posted after oldestTS, following
+// pagination cursors. Replies are returned oldest first, without the parent message.
+func (c *Client) GetThreadReplies(ctx context.Context, channelID, threadTS, oldestTS string) ([]monitor.Message, error) {
+	var messages []monitor.Message
+	cursor := ""
+
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("channel", channelID)
+		params.Set("ts", threadTS)
+		if oldestTS != "" {
+			params.Set("oldest", oldestTS)
+		}
+		params.Set("limit", fmt.Sprintf("%d", messageLimit))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		params.Set("token", c.token()) // GET requests need token as query parameter
+
+		body, err := c.makeRequest(ctx, "GET", "conversations.replies", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var response conversationsRepliesResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse replies response: %w", err)
+		}
+
+		if !response.OK {
+			return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "conversations.replies", Code: response.Error})
+		}
+
+		// Convert API response to domain types, dropping the parent Slack always includes
+		for _, msg := range response.Messages {
+			if msg.Timestamp == threadTS {
+				continue
+			}
+			messages = append(messages, msg.toMessage())
+		}
+
+		cursor = response.ResponseMetadata.NextCursor
+		if !response.HasMore || cursor == "" {
+			break
+		}
+		if page >= c.maxPages {
+			log.Printf("Warning: conversations.replies for %s/%s has more results after %d page(s), stopping at page cap", channelID, threadTS, page)
+			break
+		}
+	}
+
+	return messages, nil
+}
+
+// toMessage converts an API message to the domain type
+func (msg messageResponse) toMessage() monitor.Message {
+	return monitor.Message{
+		Timestamp:   msg.Timestamp,
+		User:        msg.User,
+		Text:        msg.Text,
+		Type:        msg.Type,
+		ThreadTS:    msg.ThreadTS,
+		ReplyCount:  msg.ReplyCount,
+		LatestReply: msg.LatestReply,
+		ReplyUsers:  msg.ReplyUsers,
+	}
+}
+
 // GetUserInfo fetches information about a user
-func (c *Client) GetUserInfo(userID string) (*monitor.User, error) {
+func (c *Client) GetUserInfo(ctx context.Context, userID string) (*monitor.User, error) {
 	params := url.Values{}
 	params.Set("user", userID)
-	params.Set("token", c.xoxcToken) // GET requests need token as query parameter
+	params.Set("token", c.token()) // GET requests need token as query parameter
 
-	body, err := c.makeRequest("GET", "users.info", params)
+	body, err := c.makeRequest(ctx, "GET", "users.info", params)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +352,7 @@ func (c *Client) GetUserInfo(userID string) (*monitor.User, error) {
 	}
 
 	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
+		return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "users.info", Code: response.Error})
 	}
 
 	// Convert API response to domain type
@@ -163,14 +363,191 @@ func (c *Client) GetUserInfo(userID string) (*monitor.User, error) {
 	}, nil
 }
 
+// GetUserGroups fetches all user groups in the workspace with their members
+func (c *Client) GetUserGroups(ctx context.Context) ([]monitor.UserGroup, error) {
+	params := url.Values{}
+	params.Set("include_users", "true")
+	params.Set("token", c.token()) // GET requests need token as query parameter
+
+	body, err := c.makeRequest(ctx, "GET", "usergroups.list", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response userGroupsListResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse user groups response: %w", err)
+	}
+
+	if !response.OK {
+		return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "usergroups.list", Code: response.Error})
+	}
+
+	// Convert API response to domain types
+	groups := make([]monitor.UserGroup, len(response.UserGroups))
+	for i, g := range response.UserGroups {
+		groups[i] = monitor.UserGroup{
+			ID:     g.ID,
+			Handle: g.Handle,
+			Name:   g.Name,
+			Users:  g.Users,
+		}
+	}
+
+	return groups, nil
+}
+
+// GetLatestTimestamps fetches the timestamp of the newest message in every
+// conversation the user belongs to with a single client.counts call, keyed by
+// channel ID. The timestamp is empty for conversations without messages.
+func (c *Client) GetLatestTimestamps(ctx context.Context) (map[string]string, error) {
+	params := url.Values{
+		"token": {c.token()},
+	}
+	body, err := c.makeRequest(ctx, "POST", "client.counts", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response clientCountsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse counts response: %w", err)
+	}
+
+	if !response.OK {
+		return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "client.counts", Code: response.Error})
+	}
+
+	latest := make(map[string]string)
+	for _, list := range [][]conversationCountResponse{response.Channels, response.MPIMs, response.IMs} {
+		for _, conv := range list {
+			latest[conv.ID] = conv.Latest
+		}
+	}
+	return latest, nil
+}
+
+// RateLimitStats returns a snapshot of the client's request and throttling counters
+func (c *Client) RateLimitStats() monitor.RateLimitStats {
+	return c.counters.snapshot()
+}
+
+// SetCredentials replaces the session tokens used by subsequent requests,
+// e.g. after the old session expired
+func (c *Client) SetCredentials(xoxcToken, xoxdToken string) {
+	c.credMu.Lock()
+	defer c.credMu.Unlock()
+	c.xoxcToken = xoxcToken
+	c.xoxdToken = xoxdToken
+}
+
+// token returns the current xoxc token
+func (c *Client) token() string {
+	c.credMu.RLock()
+	defer c.credMu.RUnlock()
+	return c.xoxcToken
+}
+
+// cookie returns the current xoxd token
+func (c *Client) cookie() string {
+	c.credMu.RLock()
+	defer c.credMu.RUnlock()
+	return c.xoxdToken
+}
+
 // GetAuthenticatedUserID returns the ID of the authenticated user
 func (c *Client) GetAuthenticatedUserID() string {
 	return c.authenticatedUserID
 }
 
-// makeRequest makes an authenticated request to the Slack API
-func (c *Client) makeRequest(method, endpoint string, params url.Values) ([]byte, error) {
-	apiURL := "https://slack.com/api/" + endpoint
+// GetTeamID returns the ID of the authenticated workspace
+func (c *Client) GetTeamID() string {
+	return c.teamID
+}
+
+// GetWorkspaceURL returns the web URL of the authenticated workspace
+func (c *Client) GetWorkspaceURL() string {
+	return c.workspaceURL
+}
+
+// makeRequest makes an authenticated, rate-limited request to the Slack API.
+// 429 and 5xx responses are retried with jittered backoff, honoring Retry-After.
+// Waiting and the request itself stop as soon as ctx is done.
+func (c *Client) makeRequest(ctx context.Context, method, endpoint string, params url.Values) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		if wait := c.limiter.reserve(endpoint); wait > 0 {
+			if err := c.wait(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := c.newRequest(ctx, method, endpoint, params)
+		if err != nil {
+			return nil, err
+		}
+
+		c.counters.requests.Add(1)
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return body, nil
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt >= c.maxRetries {
+			return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		delay := backoff(attempt)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			c.counters.rateLimited.Add(1)
+			if ra := retryAfter(resp); ra > 0 {
+				// Pause the whole tier so other callers don't trip the limit again
+				c.limiter.pause(endpoint, ra)
+				delay = withJitter(ra)
+			}
+			log.Printf("Rate limited on %s, retrying in %s (attempt %d/%d)", endpoint, delay.Round(time.Millisecond), attempt+1, c.maxRetries)
+		} else {
+			log.Printf("%s returned status %d, retrying in %s (attempt %d/%d)", endpoint, resp.StatusCode, delay.Round(time.Millisecond), attempt+1, c.maxRetries)
+		}
+
+		c.counters.retries.Add(1)
+		if err := c.wait(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait sleeps for the given duration, or until ctx is done, and records it in the counters
+func (c *Client) wait(ctx context.Context, d time.Duration) error {
+	c.counters.waited.Add(int64(d))
+	return c.sleep(ctx, d)
+}
+
+// sleepContext sleeps for d, returning ctx's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// newRequest builds an authenticated request to the Slack API
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, params url.Values) (*http.Request, error) {
+	apiURL := c.baseURL + endpoint
 
 	var req *http.Request
 	var err error
@@ -179,22 +556,29 @@ func (c *Client) makeRequest(method, endpoint string, params url.Values) ([]byte
 		if len(params) > 0 {
 			apiURL += "?" + params.Encode()
 		}
-		req, err = http.NewRequest("GET", apiURL, nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	} else {
-		req, err = http.NewRequest("POST", apiURL, strings.NewReader(params.Encode()))
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req, err = http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	c.authenticate(req)
+	return req, nil
+}
 
+// authenticate adds the session cookies and browser User-Agent to a request
+func (c *Client) authenticate(req *http.Request) {
 	// Add authentication - stealth mode uses both tokens AND two cookies
 	// Slack requires both "d" and "d-s" cookies (discovered from rusq/slackdump library)
 	// CRITICAL: xoxd goes in "d" cookie, xoxc goes in token parameter (not the other way around!)
 	dCookie := &http.Cookie{
 		Name:  "d",
-		Value: c.xoxdToken, // xoxd token goes in "d" cookie
+		Value: c.cookie(), // xoxd token goes in "d" cookie
 	}
 	req.AddCookie(dCookie)
 
@@ -211,21 +595,4 @@ func (c *Client) makeRequest(method, endpoint string, params url.Values) ([]byte
 
 	// Add browser User-Agent to match slack-mcp-server
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/136.0.0.0 Safari/537.36")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return body, nil
 }
@@ -1,7 +1,17 @@
 package slack
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+
+	"github.com/FourPalms/golang-slack-monitor"
 )
 
 // TestNewClient tests Slack client initialization
@@ -23,3 +33,177 @@ func TestNewClient(t *testing.T) {
 		t.Error("Expected initialized HTTP client")
 	}
 }
+
+// newTestClient creates a client whose requests are served by the given handler
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts = append([]Option{WithBaseURL(server.URL + "/api")}, opts...)
+	return NewClient("test-xoxc", "test-xoxd", opts...)
+}
+
+// TestClientOptions tests base URL normalization and custom transports
+func TestClientOptions(t *testing.T) {
+	client := NewClient("x", "d")
+	if client.baseURL != defaultBaseURL {
+		t.Errorf("Expected default base URL %s, got %s", defaultBaseURL, client.baseURL)
+	}
+
+	client = NewClient("x", "d", WithBaseURL("https://acme.enterprise.slack.com/api"))
+	if client.baseURL != "https://acme.enterprise.slack.com/api/" {
+		t.Errorf("Expected base URL with trailing slash, got %s", client.baseURL)
+	}
+
+	proxy, _ := url.Parse("http://proxy.internal:3128")
+	client = NewClient("x", "d", WithProxy(proxy))
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		t.Fatal("Expected cloned transport with proxy set")
+	}
+	if transport == http.DefaultTransport {
+		t.Error("Proxy settings must not modify http.DefaultTransport")
+	}
+
+	// A custom transport sees every request and takes precedence over proxy settings
+	var seen []string
+	custom := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		seen = append(seen, req.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true,"user_id":"U1"}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+	client = NewClient("x", "d", WithProxy(proxy), WithTransport(custom), WithBaseURL("http://fake.local/api/"))
+	if _, err := client.TestAuth(context.Background()); err != nil {
+		t.Fatalf("TestAuth through custom transport failed: %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "http://fake.local/api/auth.test" {
+		t.Errorf("Expected request to http://fake.local/api/auth.test, got %v", seen)
+	}
+
+	// Without the rate limit, a burst past every tier's allowance never waits
+	client = NewClient("x", "d", WithoutRateLimit())
+	for i := 0; i < 100; i++ {
+		if wait := client.limiter.reserve("conversations.list"); wait != 0 {
+			t.Fatalf("Expected no wait without a rate limit, got %s on request %d", wait, i+1)
+		}
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// TestGetDMConversationsPagination tests that conversations.list cursors are followed
+func TestGetDMConversationsPagination(t *testing.T) {
+	pages := map[string]string{
+		"":      `{"ok":true,"channels":[{"id":"D1","user":"U1"},{"id":"D2","user":"U2"}],"response_metadata":{"next_cursor":"page2"}}`,
+		"page2": `{"ok":true,"channels":[{"id":"D3","user":"U3"}],"response_metadata":{"next_cursor":"page3"}}`,
+		"page3": `{"ok":true,"channels":[{"id":"D4","user":"U4","is_user_deleted":true}],"response_metadata":{"next_cursor":""}}`,
+	}
+	requests := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/conversations.list" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, pages[r.URL.Query().Get("cursor")])
+	})
+
+	conversations, err := client.GetDMConversations(context.Background())
+	if err != nil {
+		t.Fatalf("GetDMConversations failed: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+	if len(conversations) != 4 {
+		t.Fatalf("Expected 4 conversations, got %d", len(conversations))
+	}
+	if conversations[3].ID != "D4" || !conversations[3].IsUserDeleted {
+		t.Errorf("Expected last conversation D4 (deleted), got %+v", conversations[3])
+	}
+}
+
+// TestGetConversationHistoryPagination tests that history pages are followed and the page cap is honored
+func TestGetConversationHistoryPagination(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("oldest") != "100.000000" {
+			t.Errorf("Expected oldest to be forwarded, got %q", r.URL.Query().Get("oldest"))
+		}
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			fmt.Fprint(w, `{"ok":true,"messages":[{"type":"message","user":"U1","text":"c","ts":"103.0"}],"has_more":true,"response_metadata":{"next_cursor":"p2"}}`)
+		case "p2":
+			fmt.Fprint(w, `{"ok":true,"messages":[{"type":"message","user":"U1","text":"b","ts":"102.0"}],"has_more":true,"response_metadata":{"next_cursor":"p3"}}`)
+		case "p3":
+			fmt.Fprint(w, `{"ok":true,"messages":[{"type":"message","user":"U1","text":"a","ts":"101.0"}],"has_more":false}`)
+		}
+	}
+
+	client := newTestClient(t, handler)
+	messages, err := client.GetConversationHistory(context.Background(), "D1", "100.000000")
+	if err != nil {
+		t.Fatalf("GetConversationHistory failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	if messages[0].Timestamp != "103.0" || messages[2].Timestamp != "101.0" {
+		t.Errorf("Expected newest-first ordering, got %s..%s", messages[0].Timestamp, messages[2].Timestamp)
+	}
+
+	// Page cap stops early even though the server has more, and says so
+	capped := newTestClient(t, handler, WithMaxPages(2))
+	messages, err = capped.GetConversationHistory(context.Background(), "D1", "100.000000")
+	if !errors.Is(err, monitor.ErrHistoryTruncated) {
+		t.Errorf("Expected ErrHistoryTruncated, got %v", err)
+	}
+	if len(messages) != 2 {
+		t.Errorf("Expected 2 messages with page cap, got %d", len(messages))
+	}
+}
+
+// TestAuthErrors tests that session errors are classified as auth errors and
+// that new credentials are used once set
+func TestAuthErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("d")
+		switch {
+		case r.URL.Path == "/api/conversations.history":
+			fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+		case r.FormValue("token") != "new-xoxc" || cookie == nil || cookie.Value != "new-xoxd":
+			fmt.Fprint(w, `{"ok":false,"error":"invalid_auth"}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"user_id":"U1"}`)
+		}
+	})
+
+	_, err := client.TestAuth(context.Background())
+	if !IsAuthError(err) || !errors.Is(err, monitor.ErrSessionExpired) {
+		t.Errorf("Expected an auth error, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Method != "auth.test" || apiErr.Code != "invalid_auth" {
+		t.Errorf("Expected auth.test invalid_auth APIError, got %#v", apiErr)
+	}
+
+	_, err = client.GetConversationHistory(context.Background(), "C1", "")
+	if err == nil || IsAuthError(err) {
+		t.Errorf("Expected a non-auth error, got %v", err)
+	}
+	if err != nil && err.Error() != "Slack API error: channel_not_found" {
+		t.Errorf("Unexpected error message %q", err)
+	}
+
+	client.SetCredentials("new-xoxc", "new-xoxd")
+	if userID, err := client.TestAuth(context.Background()); err != nil || userID != "U1" {
+		t.Errorf("Expected U1 with new credentials, got %q, %v", userID, err)
+	}
+}
@@ -0,0 +1,47 @@
+package slack
+
+import (
+	"errors"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// authErrorCodes are the Slack error codes meaning the session's tokens no
+// longer work, e.g. because the browser session expired or was signed out
+var authErrorCodes = map[string]bool{
+	"invalid_auth":     true,
+	"not_authed":       true,
+	"token_revoked":    true,
+	"token_expired":    true,
+	"account_inactive": true,
+}
+
+// APIError is the error code from a Slack API response with "ok": false
+type APIError struct {
+	Method string // API method that failed, e.g. "conversations.history"
+	Code   string // Slack error code, e.g. "channel_not_found"
+}
+
+// Error returns the Slack error code
+func (e *APIError) Error() string {
+	return e.Code
+}
+
+// IsAuthError reports whether the error means the session has expired or been revoked
+func (e *APIError) IsAuthError() bool {
+	return authErrorCodes[e.Code]
+}
+
+// Unwrap returns monitor.ErrSessionExpired for authentication errors, so
+// callers outside this package can detect them with errors.Is
+func (e *APIError) Unwrap() error {
+	if e.IsAuthError() {
+		return monitor.ErrSessionExpired
+	}
+	return nil
+}
+
+// IsAuthError reports whether err is, or wraps, a Slack authentication error
+func IsAuthError(err error) bool {
+	return errors.Is(err, monitor.ErrSessionExpired)
+}
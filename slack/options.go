@@ -0,0 +1,77 @@
+package slack
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Option configures optional Client settings
+type Option func(*Client)
+
+// WithMaxPages caps how many cursor pages a paginated call will follow.
+// Values of zero or less keep the default.
+func WithMaxPages(maxPages int) Option {
+	return func(c *Client) {
+		if maxPages > 0 {
+			c.maxPages = maxPages
+		}
+	}
+}
+
+// WithMaxRetries sets how many times a rate-limited or failed request is retried.
+// Zero disables retries; negative values keep the default.
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *Client) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+	}
+}
+
+// WithoutRateLimit turns off client-side throttling, for a local stand-in
+// server with no rate limits of its own. 429 responses are still retried.
+func WithoutRateLimit() Option {
+	return func(c *Client) {
+		c.limiter = nil
+	}
+}
+
+// WithBaseURL points the client at a different Web API root, such as an
+// Enterprise Grid subdomain (https://<org>.slack.com/api/) or a local fake server.
+// An empty string keeps the default.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL == "" {
+			return
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		c.baseURL = baseURL
+	}
+}
+
+// WithTransport sets the HTTP transport used for all requests.
+// When set, WithProxy and WithTLSConfig are ignored.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// WithProxy routes all requests through the given HTTP(S) proxy.
+// A nil URL keeps the default (proxy from environment).
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		c.proxyURL = proxyURL
+	}
+}
+
+// WithTLSConfig sets the TLS configuration, e.g. to trust a corporate root CA
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = tlsConfig
+	}
+}
@@ -0,0 +1,188 @@
+package slack
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+const (
+	defaultMaxRetries = 3                // Max retries after a 429 or 5xx response
+	baseBackoff       = 1 * time.Second  // First retry delay when no Retry-After is given
+	maxBackoff        = 60 * time.Second // Upper bound for computed backoff (Retry-After is always honored)
+)
+
+// tier is a Slack Web API rate limit tier
+// See https://api.slack.com/apis/rate-limits
+type tier int
+
+const (
+	tier2 tier = iota + 2 // ~20 requests per minute
+	tier3                 // ~50 requests per minute
+	tier4                 // ~100 requests per minute
+)
+
+// tierLimits defines the sustained rate and burst size for each tier
+var tierLimits = map[tier]struct {
+	perMinute int
+	burst     int
+}{
+	tier2: {perMinute: 20, burst: 5},
+	tier3: {perMinute: 50, burst: 10},
+	tier4: {perMinute: 100, burst: 20},
+}
+
+// methodTiers maps API methods to their documented tier; unlisted methods use tier3
+var methodTiers = map[string]tier{
+	"auth.test":             tier4,
+	"client.counts":         tier3,
+	"conversations.list":    tier2,
+	"conversations.history": tier3,
+	"conversations.info":    tier3,
+	"rtm.connect":           tier2,
+	"users.info":            tier4,
+	"usergroups.list":       tier2,
+}
+
+// tierFor returns the rate limit tier for an API method
+func tierFor(endpoint string) tier {
+	if t, ok := methodTiers[endpoint]; ok {
+		return t
+	}
+	return tier3
+}
+
+// tokenBucket is a simple token bucket that also honors server-imposed pauses
+type tokenBucket struct {
+	mu          sync.Mutex
+	tokens      float64
+	capacity    float64
+	refillRate  float64 // tokens per second
+	lastRefill  time.Time
+	pausedUntil time.Time // Set from Retry-After; no tokens are handed out before this
+}
+
+// newTokenBucket creates a full bucket for the given rate and burst size
+func newTokenBucket(perMinute, burst int) *tokenBucket {
+	return &tokenBucket{
+		tokens:     float64(burst),
+		capacity:   float64(burst),
+		refillRate: float64(perMinute) / 60,
+		lastRefill: time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.lastRefill = now
+
+	// Tokens may go negative; the deficit is the queue of callers already waiting
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.refillRate * float64(time.Second))
+	}
+	if pause := b.pausedUntil.Sub(now); pause > wait {
+		wait = pause
+	}
+	return wait
+}
+
+// pause blocks the bucket until the given duration has elapsed
+func (b *tokenBucket) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// rateLimiter holds one token bucket per tier, shared by all requests from a Client
+type rateLimiter struct {
+	buckets map[tier]*tokenBucket
+}
+
+// newRateLimiter creates a limiter with buckets for every known tier
+func newRateLimiter() *rateLimiter {
+	buckets := make(map[tier]*tokenBucket, len(tierLimits))
+	for t, limit := range tierLimits {
+		buckets[t] = newTokenBucket(limit.perMinute, limit.burst)
+	}
+	return &rateLimiter{buckets: buckets}
+}
+
+// reserve returns how long to wait before calling the given endpoint. A nil
+// limiter never waits.
+func (l *rateLimiter) reserve(endpoint string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	return l.buckets[tierFor(endpoint)].reserve()
+}
+
+// pause stops all requests in the endpoint's tier for the given duration
+func (l *rateLimiter) pause(endpoint string, d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.buckets[tierFor(endpoint)].pause(d)
+}
+
+// rateLimitCounters is the concurrency-safe backing store for monitor.RateLimitStats
+type rateLimitCounters struct {
+	requests    atomic.Int64
+	rateLimited atomic.Int64
+	retries     atomic.Int64
+	waited      atomic.Int64 // nanoseconds
+}
+
+// snapshot returns the current counter values
+func (c *rateLimitCounters) snapshot() monitor.RateLimitStats {
+	return monitor.RateLimitStats{
+		Requests:    c.requests.Load(),
+		RateLimited: c.rateLimited.Load(),
+		Retries:     c.retries.Load(),
+		Waited:      time.Duration(c.waited.Load()),
+	}
+}
+
+// retryAfter parses the Retry-After header (in seconds), returning 0 if absent or invalid
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// backoff returns the exponential delay for the given retry attempt (0-based) with up to 50% jitter added
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return withJitter(d)
+}
+
+// withJitter adds up to 50% random jitter so concurrent retries don't line up
+func withJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
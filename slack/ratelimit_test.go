@@ -0,0 +1,143 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestTokenBucket tests that the bucket allows a burst and then spaces requests out
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(60, 2) // 1 token per second, burst of 2
+
+	if wait := bucket.reserve(); wait != 0 {
+		t.Errorf("First request should not wait, got %s", wait)
+	}
+	if wait := bucket.reserve(); wait != 0 {
+		t.Errorf("Second request (within burst) should not wait, got %s", wait)
+	}
+	if wait := bucket.reserve(); wait < 900*time.Millisecond || wait > time.Second {
+		t.Errorf("Third request should wait ~1s, got %s", wait)
+	}
+
+	// A server-imposed pause overrides the bucket's own schedule
+	bucket.pause(5 * time.Second)
+	if wait := bucket.reserve(); wait < 4900*time.Millisecond {
+		t.Errorf("Request during pause should wait ~5s, got %s", wait)
+	}
+}
+
+// TestMakeRequestRetriesOn429 tests that 429 responses are retried after Retry-After
+func TestMakeRequestRetriesOn429(t *testing.T) {
+	attempts := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"user":{"id":"U1","name":"alice"}}`)
+	})
+
+	var slept []time.Duration
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	user, err := client.GetUserInfo(context.Background(), "U1")
+	if err != nil {
+		t.Fatalf("Expected success after retries, got: %v", err)
+	}
+	if user.Name != "alice" {
+		t.Errorf("Expected user 'alice', got '%s'", user.Name)
+	}
+
+	stats := client.RateLimitStats()
+	if stats.Requests != 3 || stats.RateLimited != 2 || stats.Retries != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// Both backoffs honor Retry-After (2s plus up to 50% jitter)
+	var backoffs int
+	for _, d := range slept {
+		if d >= 2*time.Second {
+			backoffs++
+			if d > 3*time.Second {
+				t.Errorf("Backoff %s exceeds Retry-After plus jitter", d)
+			}
+		}
+	}
+	if backoffs != 2 {
+		t.Errorf("Expected 2 Retry-After backoffs, got %d (slept %v)", backoffs, slept)
+	}
+}
+
+// TestMakeRequestGivesUp tests that retries stop after the configured maximum
+func TestMakeRequestGivesUp(t *testing.T) {
+	attempts := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithMaxRetries(1))
+	client.sleep = func(context.Context, time.Duration) error { return nil }
+
+	if _, err := client.GetUserInfo(context.Background(), "U1"); err == nil {
+		t.Error("Expected error after exhausting retries")
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts (1 retry), got %d", attempts)
+	}
+}
+
+// TestMakeRequestNoRetryOnClientError tests that non-retryable statuses fail immediately
+func TestMakeRequestNoRetryOnClientError(t *testing.T) {
+	attempts := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	client.sleep = func(context.Context, time.Duration) error { return nil }
+
+	if _, err := client.GetUserInfo(context.Background(), "U1"); err == nil {
+		t.Error("Expected error for 400 response")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d", attempts)
+	}
+}
+
+// TestMakeRequestHonorsContext tests that a cancelled context stops both a
+// request in flight and a backoff wait
+func TestMakeRequestHonorsContext(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // Never answers
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.GetUserInfo(ctx, "U1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Request took %s after its deadline", elapsed)
+	}
+
+	limited := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	if _, err := limited.GetUserInfo(ctx, "U1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Backoff ignored cancellation, took %s", elapsed)
+	}
+}
@@ -0,0 +1,127 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+const (
+	rtmPingInterval = 30 * time.Second    // How often the connection is pinged to keep it alive
+	rtmReadTimeout  = 2 * rtmPingInterval // Silence after which the connection is considered dead
+)
+
+// ConnectRealtime calls rtm.connect and opens the returned websocket with the
+// same session credentials as API requests. The connection is pinged
+// periodically and closed if the server stops answering.
+func (c *Client) ConnectRealtime(ctx context.Context) (monitor.RealtimeConnection, error) {
+	params := url.Values{
+		"token": {c.token()},
+	}
+	body, err := c.makeRequest(ctx, "POST", "rtm.connect", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response rtmConnectResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse rtm.connect response: %w", err)
+	}
+
+	if !response.OK {
+		return nil, fmt.Errorf("Slack API error: %w", &APIError{Method: "rtm.connect", Code: response.Error})
+	}
+
+	// The websocket stays open indefinitely, so it can't share the API client's timeout
+	wsClient := &http.Client{Transport: c.httpClient.Transport}
+	ws, err := dialWebsocket(ctx, wsClient, response.URL, c.authenticate)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &rtmConnection{ws: ws, done: make(chan struct{})}
+	conn.lastRead.Store(time.Now().UnixNano())
+	go conn.keepalive()
+	return conn, nil
+}
+
+// rtmConnection implements monitor.RealtimeConnection over a websocket
+type rtmConnection struct {
+	ws        *wsConn
+	lastRead  atomic.Int64 // Unix nanoseconds of the last message from the server
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NextEvent returns the next message event, skipping other event types
+func (c *rtmConnection) NextEvent() (monitor.MessageEvent, error) {
+	for {
+		data, err := c.ws.readMessage()
+		if err != nil {
+			return monitor.MessageEvent{}, err
+		}
+		c.lastRead.Store(time.Now().UnixNano())
+
+		var event rtmEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			continue
+		}
+		switch event.Type {
+		case "message":
+			if event.Channel == "" {
+				continue
+			}
+			return monitor.MessageEvent{
+				ChannelID: event.Channel,
+				User:      event.User,
+				Timestamp: event.Timestamp,
+				ThreadTS:  event.ThreadTS,
+			}, nil
+		case "goodbye":
+			return monitor.MessageEvent{}, errors.New("server asked to reconnect")
+		case "error":
+			return monitor.MessageEvent{}, fmt.Errorf("real-time API error %d: %s", event.Error.Code, event.Error.Msg)
+		}
+	}
+}
+
+// Close closes the connection
+func (c *rtmConnection) Close() error {
+	err := errors.New("already closed")
+	c.closeOnce.Do(func() {
+		close(c.done)
+		err = c.ws.Close()
+	})
+	return err
+}
+
+// keepalive pings the server until the connection is closed, and closes it if
+// nothing has been received for rtmReadTimeout
+func (c *rtmConnection) keepalive() {
+	ticker := time.NewTicker(rtmPingInterval)
+	defer ticker.Stop()
+	for id := 1; ; id++ {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		if time.Since(time.Unix(0, c.lastRead.Load())) > rtmReadTimeout {
+			c.Close()
+			return
+		}
+		ping, _ := json.Marshal(map[string]interface{}{"id": id, "type": "ping"})
+		if err := c.ws.writeText(ping); err != nil {
+			c.Close()
+			return
+		}
+	}
+}
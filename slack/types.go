@@ -3,30 +3,63 @@ package slack
 // conversationResponse represents a Slack conversation (DM or channel) from API
 type conversationResponse struct {
 	ID            string `json:"id"`
+	Name          string `json:"name"`            // Channel name (empty for DMs)
 	User          string `json:"user"`            // For DMs, this is the other user's ID
 	IsUserDeleted bool   `json:"is_user_deleted"` // Whether the user has been deleted
+	IsIM          bool   `json:"is_im"`
+	IsMPIM        bool   `json:"is_mpim"`
+	IsPrivate     bool   `json:"is_private"`
+	IsMember      bool   `json:"is_member"`
+}
+
+// conversationsInfoResponse represents the API response from conversations.info
+type conversationsInfoResponse struct {
+	OK      bool                 `json:"ok"`
+	Channel conversationResponse `json:"channel"`
+	Error   string               `json:"error"`
+}
+
+// responseMetadata carries the pagination cursor returned by cursor-paginated endpoints
+type responseMetadata struct {
+	NextCursor string `json:"next_cursor"` // Empty when there are no more pages
 }
 
 // conversationsListResponse represents the API response from conversations.list
 type conversationsListResponse struct {
-	OK       bool                   `json:"ok"`
-	Channels []conversationResponse `json:"channels"`
-	Error    string                 `json:"error"`
+	OK               bool                   `json:"ok"`
+	Channels         []conversationResponse `json:"channels"`
+	ResponseMetadata responseMetadata       `json:"response_metadata"`
+	Error            string                 `json:"error"`
 }
 
 // messageResponse represents a single Slack message from API
 type messageResponse struct {
-	Type      string `json:"type"`
-	User      string `json:"user"`
-	Text      string `json:"text"`
-	Timestamp string `json:"ts"`
+	Type        string   `json:"type"`
+	User        string   `json:"user"`
+	Text        string   `json:"text"`
+	Timestamp   string   `json:"ts"`
+	ThreadTS    string   `json:"thread_ts"`
+	ReplyCount  int      `json:"reply_count"`
+	LatestReply string   `json:"latest_reply"`
+	ReplyUsers  []string `json:"reply_users"`
 }
 
 // conversationsHistoryResponse represents the API response from conversations.history
 type conversationsHistoryResponse struct {
-	OK       bool              `json:"ok"`
-	Messages []messageResponse `json:"messages"`
-	Error    string            `json:"error"`
+	OK               bool              `json:"ok"`
+	Messages         []messageResponse `json:"messages"`
+	HasMore          bool              `json:"has_more"`
+	ResponseMetadata responseMetadata  `json:"response_metadata"`
+	Error            string            `json:"error"`
+}
+
+// conversationsRepliesResponse represents the API response from conversations.replies
+type conversationsRepliesResponse struct {
+	OK               bool              `json:"ok"`
+	Messages         []messageResponse `json:"messages"` // Parent first, then replies oldest first
+	HasMore          bool              `json:"has_more"`
+	ResponseMetadata responseMetadata  `json:"response_metadata"`
+	Error            string            `json:"error"`
 }
 
 // userResponse represents a Slack user from API
@@ -43,6 +76,40 @@ type usersInfoResponse struct {
 	Error string       `json:"error"`
 }
 
+// userGroupResponse represents a Slack user group from API
+type userGroupResponse struct {
+	ID     string   `json:"id"`
+	Handle string   `json:"handle"`
+	Name   string   `json:"name"`
+	Users  []string `json:"users"` // Only present with include_users=true
+}
+
+// userGroupsListResponse represents the API response from usergroups.list
+type userGroupsListResponse struct {
+	OK         bool                `json:"ok"`
+	UserGroups []userGroupResponse `json:"usergroups"`
+	Error      string              `json:"error"`
+}
+
+// conversationCountResponse is one conversation's read state from client.counts
+type conversationCountResponse struct {
+	ID           string `json:"id"`
+	LastRead     string `json:"last_read"`
+	Latest       string `json:"latest"` // Timestamp of the newest message
+	MentionCount int    `json:"mention_count"`
+	HasUnreads   bool   `json:"has_unreads"`
+}
+
+// clientCountsResponse represents the API response from client.counts, the
+// web client's bulk unread-state call
+type clientCountsResponse struct {
+	OK       bool                        `json:"ok"`
+	Channels []conversationCountResponse `json:"channels"`
+	MPIMs    []conversationCountResponse `json:"mpims"`
+	IMs      []conversationCountResponse `json:"ims"`
+	Error    string                      `json:"error"`
+}
+
 // authTestResponse represents the API response from auth.test
 type authTestResponse struct {
 	OK     bool   `json:"ok"`
@@ -53,3 +120,24 @@ type authTestResponse struct {
 	UserID string `json:"user_id"`
 	Error  string `json:"error"`
 }
+
+// rtmConnectResponse represents the API response from rtm.connect
+type rtmConnectResponse struct {
+	OK    bool   `json:"ok"`
+	URL   string `json:"url"` // Websocket URL, valid for 30 seconds
+	Error string `json:"error"`
+}
+
+// rtmEvent represents an event received over the real-time websocket
+type rtmEvent struct {
+	Type      string `json:"type"`
+	Subtype   string `json:"subtype"`
+	Channel   string `json:"channel"`
+	User      string `json:"user"`
+	Timestamp string `json:"ts"`
+	ThreadTS  string `json:"thread_ts"`
+	Error     struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
@@ -0,0 +1,231 @@
+package slack
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is appended to the handshake key to derive the accept key (RFC 6455 section 1.3)
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Websocket frame opcodes
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+const (
+	websocketHandshakeTimeout = 30 * time.Second
+	maxWebsocketMessage       = 4 << 20 // Largest message accepted from the server
+)
+
+// errWebsocketClosed is returned once the server has closed the connection
+var errWebsocketClosed = errors.New("websocket closed by server")
+
+// wsConn is a minimal client-side websocket: just enough of RFC 6455 for
+// Slack's real-time API (text messages, ping/pong and close, no extensions)
+type wsConn struct {
+	conn    io.ReadWriteCloser
+	r       *bufio.Reader
+	writeMu sync.Mutex
+	cancel  context.CancelFunc // Releases the upgraded request
+}
+
+// dialWebsocket opens a websocket to wsURL ("ws://" or "wss://") through
+// client's transport, so proxy and TLS settings apply. prepare adds
+// authentication to the handshake request.
+func dialWebsocket(ctx context.Context, client *http.Client, wsURL string, prepare func(*http.Request)) (*wsConn, error) {
+	httpURL := wsURL
+	switch {
+	case strings.HasPrefix(wsURL, "wss://"):
+		httpURL = "https://" + strings.TrimPrefix(wsURL, "wss://")
+	case strings.HasPrefix(wsURL, "ws://"):
+		httpURL = "http://" + strings.TrimPrefix(wsURL, "ws://")
+	default:
+		return nil, fmt.Errorf("invalid websocket URL %q", wsURL)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	// The request's context lives as long as the connection, so the handshake
+	// timeout cancels it only until the server answers
+	connCtx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(connCtx, "GET", httpURL, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create websocket request: %w", err)
+	}
+	prepare(req)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	timer := time.AfterFunc(websocketHandshakeTimeout, cancel)
+	resp, err := client.Do(req)
+	if !timer.Stop() && err == nil {
+		resp.Body.Close()
+		err = errors.New("handshake timed out")
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("websocket handshake failed: %w", err)
+	}
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if resp.StatusCode != http.StatusSwitchingProtocols || !ok {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("websocket handshake failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		cancel()
+		return nil, errors.New("websocket handshake failed: bad Sec-WebSocket-Accept")
+	}
+	return &wsConn{conn: conn, r: bufio.NewReader(conn), cancel: cancel}, nil
+}
+
+// acceptKey returns the Sec-WebSocket-Accept value for a handshake key
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// readMessage returns the next complete data message, answering pings along the way
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, payload) // Best effort; the connection is going away
+			return nil, errWebsocketClosed
+		}
+
+		message = append(message, payload...)
+		if len(message) > maxWebsocketMessage {
+			return nil, fmt.Errorf("websocket message exceeds %d bytes", maxWebsocketMessage)
+		}
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame reads one frame, unmasking its payload if the server masked it
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxWebsocketMessage {
+		return false, 0, nil, fmt.Errorf("websocket frame of %d bytes is too large", length)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeText sends a text message
+func (c *wsConn) writeText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// writeFrame sends a single masked frame, as clients must (RFC 6455 section 5.3)
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the connection
+func (c *wsConn) Close() error {
+	c.writeFrame(opClose, nil)
+	err := c.conn.Close()
+	c.cancel()
+	return err
+}
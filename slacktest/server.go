@@ -0,0 +1,748 @@
+// Package slacktest provides an in-process fake of the Slack Web API for tests.
+//
+// The fake implements the subset of methods the monitor uses (auth.test,
+// client.counts, conversations.list, conversations.info, conversations.history,
+// conversations.replies, users.info, usergroups.list and rtm.connect), checks the same stealth-mode credentials the real client sends
+// (xoxc token parameter plus "d" and "d-s" cookies), and can be scripted to
+// paginate, rate limit, slow down or fail individual methods, or reject authentication.
+// Clients connected to the real-time websocket receive a message event for
+// every message added.
+package slacktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default credentials accepted by a new Server
+const (
+	DefaultXoxcToken = "xoxc-test-token"
+	DefaultXoxdToken = "xoxd-test-token"
+	DefaultUserID    = "UME"
+	DefaultUserName  = "me"
+	DefaultTeam      = "Test Workspace"
+	DefaultTeamID    = "T0001"
+)
+
+// Conversation types, matching the values accepted by conversations.list "types"
+const (
+	TypeIM             = "im"
+	TypeMPIM           = "mpim"
+	TypePrivateChannel = "private_channel"
+	TypePublicChannel  = "public_channel"
+)
+
+// Conversation is a scripted conversation
+type Conversation struct {
+	ID            string
+	Name          string // Channel name (empty for DMs)
+	Type          string // One of the Type* constants (defaults to TypeIM)
+	User          string // Other user's ID for DMs
+	IsUserDeleted bool
+	IsArchived    bool
+	IsMember      bool // Whether the authenticated user joined (public channels only; others are always joined)
+}
+
+// Message is a scripted message
+type Message struct {
+	Type      string // Defaults to "message"
+	Subtype   string
+	User      string
+	Text      string
+	Timestamp string // Assigned by the server when empty
+	ThreadTS  string // Parent timestamp for thread replies
+}
+
+// User is a scripted user
+type User struct {
+	ID       string
+	Name     string
+	RealName string
+	Deleted  bool
+}
+
+// UserGroup is a scripted user group
+type UserGroup struct {
+	ID     string
+	Handle string
+	Name   string
+	Users  []string
+}
+
+// rateLimit is a scripted run of 429 responses for one method
+type rateLimit struct {
+	remaining  int
+	retryAfter int
+}
+
+// conversationState holds a conversation and its messages
+type conversationState struct {
+	Conversation
+	messages []Message            // Top-level messages, oldest first
+	replies  map[string][]Message // Thread replies keyed by parent ts, oldest first
+}
+
+// Server is a fake Slack Web API backed by an httptest.Server
+type Server struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	xoxcToken     string
+	xoxdToken     string
+	authError     string // When set, every request fails with this error
+	userID        string
+	users         map[string]User
+	userGroups    []UserGroup
+	conversations []*conversationState
+	pageSize      int
+	rateLimits    map[string]*rateLimit
+	methodErrors  map[string]string        // method -> Slack error returned for every call
+	delays        map[string]time.Duration // method -> time to wait before answering
+	requests      map[string]int
+	inFlight      map[string]int // method -> requests currently being answered
+	peakInFlight  map[string]int // method -> most requests answered at once
+	lastTS        time.Time
+	realtime      map[*realtimeConn]bool // Connected real-time websocket clients
+}
+
+// NewServer starts a fake Slack server with default credentials and the
+// authenticated user already registered. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		xoxcToken:    DefaultXoxcToken,
+		xoxdToken:    DefaultXoxdToken,
+		userID:       DefaultUserID,
+		users:        make(map[string]User),
+		rateLimits:   make(map[string]*rateLimit),
+		methodErrors: make(map[string]string),
+		delays:       make(map[string]time.Duration),
+		requests:     make(map[string]int),
+		inFlight:     make(map[string]int),
+		peakInFlight: make(map[string]int),
+		realtime:     make(map[*realtimeConn]bool),
+	}
+	s.users[DefaultUserID] = User{ID: DefaultUserID, Name: DefaultUserName, RealName: DefaultUserName}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.DisconnectRealtime()
+	s.server.Close()
+}
+
+// URL returns the Web API base URL to pass to slack.WithBaseURL
+func (s *Server) URL() string {
+	return s.server.URL + "/api/"
+}
+
+// UserID returns the ID of the authenticated user
+func (s *Server) UserID() string {
+	return s.userID
+}
+
+// SetTokens changes the credentials the server accepts
+func (s *Server) SetTokens(xoxcToken, xoxdToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.xoxcToken = xoxcToken
+	s.xoxdToken = xoxdToken
+}
+
+// SetAuthError makes every request fail with the given Slack error
+// (e.g. "invalid_auth", "token_revoked"). An empty string restores normal behavior.
+func (s *Server) SetAuthError(slackError string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authError = slackError
+}
+
+// SetPageSize caps the number of items per page, regardless of the requested limit,
+// so pagination can be exercised with small fixtures. Zero uses the requested limit.
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageSize = n
+}
+
+// RateLimit makes the next n calls to method return HTTP 429 with the given Retry-After seconds
+func (s *Server) RateLimit(method string, n, retryAfterSecs int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimits[method] = &rateLimit{remaining: n, retryAfter: retryAfterSecs}
+}
+
+// FailMethod makes every call to method fail with the given Slack error code
+// (e.g. "unknown_method"); an empty code restores normal behaviour
+func (s *Server) FailMethod(method, slackError string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slackError == "" {
+		delete(s.methodErrors, method)
+		return
+	}
+	s.methodErrors[method] = slackError
+}
+
+// Delay makes every call to method wait d before it is answered, or until the
+// client gives up; zero restores normal behaviour
+func (s *Server) Delay(method string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays[method] = d
+}
+
+// RequestCount returns how many requests have been made to method (including rejected ones)
+func (s *Server) RequestCount(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method]
+}
+
+// PeakConcurrency returns the most requests to method that were being
+// answered at the same time, e.g. to check requests overlap during a Delay
+func (s *Server) PeakConcurrency(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peakInFlight[method]
+}
+
+// AddUser registers a user for users.info
+func (s *Server) AddUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = user
+}
+
+// AddUserGroup registers a user group for usergroups.list
+func (s *Server) AddUserGroup(group UserGroup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userGroups = append(s.userGroups, group)
+}
+
+// AddConversation registers a conversation
+func (s *Server) AddConversation(conv Conversation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conv.Type == "" {
+		conv.Type = TypeIM
+	}
+	s.conversations = append(s.conversations, &conversationState{
+		Conversation: conv,
+		replies:      make(map[string][]Message),
+	})
+}
+
+// AddDM registers a DM conversation with the given user
+func (s *Server) AddDM(channelID, userID string) {
+	s.AddConversation(Conversation{ID: channelID, Type: TypeIM, User: userID})
+}
+
+// AddMessage appends a message to a conversation and returns its timestamp.
+// If msg.ThreadTS is set and differs from the message's own timestamp it is
+// stored as a thread reply instead of a top-level message.
+func (s *Server) AddMessage(channelID string, msg Message) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv := s.findConversation(channelID)
+	if conv == nil {
+		panic(fmt.Sprintf("slacktest: unknown conversation %s", channelID))
+	}
+	if msg.Type == "" {
+		msg.Type = "message"
+	}
+	if msg.Timestamp == "" {
+		msg.Timestamp = s.nextTimestamp()
+	}
+
+	if msg.ThreadTS != "" && msg.ThreadTS != msg.Timestamp {
+		conv.replies[msg.ThreadTS] = append(conv.replies[msg.ThreadTS], msg)
+		sortMessages(conv.replies[msg.ThreadTS])
+	} else {
+		conv.messages = append(conv.messages, msg)
+		sortMessages(conv.messages)
+	}
+
+	if !conv.IsArchived && (conv.Type != TypePublicChannel || conv.IsMember) {
+		event := map[string]interface{}{
+			"type":    msg.Type,
+			"channel": conv.ID,
+			"user":    msg.User,
+			"text":    msg.Text,
+			"ts":      msg.Timestamp,
+		}
+		if msg.Subtype != "" {
+			event["subtype"] = msg.Subtype
+		}
+		if msg.ThreadTS != "" {
+			event["thread_ts"] = msg.ThreadTS
+		}
+		s.broadcast(event)
+	}
+	return msg.Timestamp
+}
+
+// PostMessage appends a top-level message from userID and returns its timestamp
+func (s *Server) PostMessage(channelID, userID, text string) string {
+	return s.AddMessage(channelID, Message{User: userID, Text: text})
+}
+
+// PostReply appends a thread reply from userID and returns its timestamp
+func (s *Server) PostReply(channelID, threadTS, userID, text string) string {
+	return s.AddMessage(channelID, Message{User: userID, Text: text, ThreadTS: threadTS})
+}
+
+// findConversation returns the conversation with the given ID, or nil. Caller holds s.mu.
+func (s *Server) findConversation(channelID string) *conversationState {
+	for _, conv := range s.conversations {
+		if conv.ID == channelID {
+			return conv
+		}
+	}
+	return nil
+}
+
+// nextTimestamp returns a unique, increasing Slack timestamp based on the wall clock. Caller holds s.mu.
+func (s *Server) nextTimestamp() string {
+	now := time.Now().Truncate(time.Microsecond)
+	if !now.After(s.lastTS) {
+		now = s.lastTS.Add(time.Microsecond)
+	}
+	s.lastTS = now
+	return fmt.Sprintf("%d.%06d", now.Unix(), now.Nanosecond()/1000)
+}
+
+// handle dispatches an API request after checking credentials and scripted failures
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == realtimePath {
+		s.serveRealtime(w, r)
+		return
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	delay := s.delays[method]
+	s.inFlight[method]++
+	if s.inFlight[method] > s.peakInFlight[method] {
+		s.peakInFlight[method] = s.inFlight[method]
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight[method]--
+		s.mu.Unlock()
+	}()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[method]++
+
+	if limit := s.rateLimits[method]; limit != nil && limit.remaining > 0 {
+		limit.remaining--
+		w.Header().Set("Retry-After", strconv.Itoa(limit.retryAfter))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	if errCode := s.checkAuth(r); errCode != "" {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": errCode})
+		return
+	}
+	if errCode := s.methodErrors[method]; errCode != "" {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": errCode})
+		return
+	}
+
+	switch method {
+	case "auth.test":
+		s.authTest(w)
+	case "client.counts":
+		s.clientCounts(w)
+	case "conversations.list":
+		s.conversationsList(w, r)
+	case "conversations.info":
+		s.conversationsInfo(w, r)
+	case "conversations.history":
+		s.conversationsHistory(w, r)
+	case "conversations.replies":
+		s.conversationsReplies(w, r)
+	case "rtm.connect":
+		s.rtmConnect(w)
+	case "users.info":
+		s.usersInfo(w, r)
+	case "usergroups.list":
+		s.userGroupsList(w, r)
+	default:
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "unknown_method"})
+	}
+}
+
+// checkAuth validates the token parameter and session cookies, returning a Slack error code on failure
+func (s *Server) checkAuth(r *http.Request) string {
+	if s.authError != "" {
+		return s.authError
+	}
+
+	token := r.Form.Get("token")
+	d, err := r.Cookie("d")
+	if token == "" || err != nil {
+		return "not_authed"
+	}
+	ds, err := r.Cookie("d-s")
+	if err != nil {
+		return "not_authed"
+	}
+	if _, err := strconv.ParseInt(ds.Value, 10, 64); err != nil {
+		return "invalid_auth"
+	}
+	if token != s.xoxcToken || d.Value != s.xoxdToken {
+		return "invalid_auth"
+	}
+	return ""
+}
+
+func (s *Server) authTest(w http.ResponseWriter) {
+	writeJSON(w, map[string]interface{}{
+		"ok":      true,
+		"url":     "https://test.slack.com/",
+		"team":    DefaultTeam,
+		"team_id": DefaultTeamID,
+		"user":    s.users[s.userID].Name,
+		"user_id": s.userID,
+	})
+}
+
+// clientCounts reports the newest top-level message of every joined conversation
+func (s *Server) clientCounts(w http.ResponseWriter) {
+	lists := map[string][]map[string]interface{}{"channels": {}, "mpims": {}, "ims": {}}
+	for _, conv := range s.conversations {
+		if conv.IsArchived || (conv.Type == TypePublicChannel && !conv.IsMember) {
+			continue
+		}
+		latest := ""
+		if n := len(conv.messages); n > 0 {
+			latest = conv.messages[n-1].Timestamp
+		}
+		key := "channels"
+		switch conv.Type {
+		case TypeIM:
+			key = "ims"
+		case TypeMPIM:
+			key = "mpims"
+		}
+		lists[key] = append(lists[key], map[string]interface{}{
+			"id":          conv.ID,
+			"latest":      latest,
+			"has_unreads": latest != "",
+		})
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok":       true,
+		"channels": lists["channels"],
+		"mpims":    lists["mpims"],
+		"ims":      lists["ims"],
+	})
+}
+
+func (s *Server) conversationsList(w http.ResponseWriter, r *http.Request) {
+	types := map[string]bool{}
+	for _, t := range strings.Split(r.Form.Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types[t] = true
+		}
+	}
+	if len(types) == 0 {
+		types[TypePublicChannel] = true // Slack's default
+	}
+	excludeArchived := r.Form.Get("exclude_archived") == "true"
+
+	var channels []map[string]interface{}
+	for _, conv := range s.conversations {
+		if !types[conv.Type] || (excludeArchived && conv.IsArchived) {
+			continue
+		}
+		channels = append(channels, conversationJSON(&conv.Conversation))
+	}
+
+	page, next, ok := s.paginate(len(channels), r)
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "invalid_cursor"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"channels":          sliceOrEmpty(channels, page),
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func (s *Server) conversationsInfo(w http.ResponseWriter, r *http.Request) {
+	conv := s.findConversation(r.Form.Get("channel"))
+	if conv == nil {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{"ok": true, "channel": conversationJSON(&conv.Conversation)})
+}
+
+func (s *Server) conversationsHistory(w http.ResponseWriter, r *http.Request) {
+	conv := s.findConversation(r.Form.Get("channel"))
+	if conv == nil {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		return
+	}
+
+	// History is returned newest first, excluding messages at or before "oldest"
+	oldest := r.Form.Get("oldest")
+	var messages []map[string]interface{}
+	for i := len(conv.messages) - 1; i >= 0; i-- {
+		msg := conv.messages[i]
+		if oldest != "" && compareTimestamps(msg.Timestamp, oldest) <= 0 {
+			continue
+		}
+		messages = append(messages, messageJSON(msg, conv.replies[msg.Timestamp]))
+	}
+
+	page, next, ok := s.paginate(len(messages), r)
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "invalid_cursor"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"messages":          sliceOrEmpty(messages, page),
+		"has_more":          next != "",
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func (s *Server) conversationsReplies(w http.ResponseWriter, r *http.Request) {
+	conv := s.findConversation(r.Form.Get("channel"))
+	if conv == nil {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		return
+	}
+
+	threadTS := r.Form.Get("ts")
+	var parent *Message
+	for i := range conv.messages {
+		if conv.messages[i].Timestamp == threadTS {
+			parent = &conv.messages[i]
+			break
+		}
+	}
+	if parent == nil {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "thread_not_found"})
+		return
+	}
+
+	// Replies are returned oldest first, always led by the parent message
+	oldest := r.Form.Get("oldest")
+	replies := conv.replies[threadTS]
+	messages := []map[string]interface{}{messageJSON(*parent, replies)}
+	for _, reply := range replies {
+		if oldest != "" && compareTimestamps(reply.Timestamp, oldest) <= 0 {
+			continue
+		}
+		messages = append(messages, messageJSON(reply, nil))
+	}
+
+	page, next, ok := s.paginate(len(messages), r)
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "invalid_cursor"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"messages":          sliceOrEmpty(messages, page),
+		"has_more":          next != "",
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func (s *Server) usersInfo(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.users[r.Form.Get("user")]
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "user_not_found"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok": true,
+		"user": map[string]interface{}{
+			"id":        user.ID,
+			"name":      user.Name,
+			"real_name": user.RealName,
+			"deleted":   user.Deleted,
+		},
+	})
+}
+
+func (s *Server) userGroupsList(w http.ResponseWriter, r *http.Request) {
+	includeUsers := r.Form.Get("include_users") == "true"
+	groups := []map[string]interface{}{}
+	for _, group := range s.userGroups {
+		out := map[string]interface{}{
+			"id":     group.ID,
+			"handle": group.Handle,
+			"name":   group.Name,
+		}
+		if includeUsers {
+			out["users"] = group.Users
+		}
+		groups = append(groups, out)
+	}
+	writeJSON(w, map[string]interface{}{"ok": true, "usergroups": groups})
+}
+
+// pageRange is a half-open index range into a result list
+type pageRange struct {
+	start, end int
+}
+
+// paginate computes the page for the request's cursor and limit. Cursors are opaque
+// offsets into the result list. ok is false if the cursor is malformed.
+func (s *Server) paginate(total int, r *http.Request) (page pageRange, nextCursor string, ok bool) {
+	start := 0
+	if cursor := r.Form.Get("cursor"); cursor != "" {
+		offset, err := strconv.Atoi(strings.TrimPrefix(cursor, "offset:"))
+		if err != nil || offset < 0 || offset > total {
+			return pageRange{}, "", false
+		}
+		start = offset
+	}
+
+	limit, _ := strconv.Atoi(r.Form.Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	if s.pageSize > 0 && s.pageSize < limit {
+		limit = s.pageSize
+	}
+
+	end := start + limit
+	if end >= total {
+		return pageRange{start, total}, "", true
+	}
+	return pageRange{start, end}, fmt.Sprintf("offset:%d", end), true
+}
+
+// sliceOrEmpty returns the page of items, never nil so it encodes as []
+func sliceOrEmpty(items []map[string]interface{}, page pageRange) []map[string]interface{} {
+	if page.start >= page.end {
+		return []map[string]interface{}{}
+	}
+	return items[page.start:page.end]
+}
+
+// conversationJSON renders a conversation as conversations.list does
+func conversationJSON(conv *Conversation) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":          conv.ID,
+		"is_im":       conv.Type == TypeIM,
+		"is_mpim":     conv.Type == TypeMPIM,
+		"is_private":  conv.Type == TypePrivateChannel || conv.Type == TypeMPIM,
+		"is_channel":  conv.Type == TypePublicChannel || conv.Type == TypePrivateChannel,
+		"is_archived": conv.IsArchived,
+		"is_member":   conv.IsMember || conv.Type != TypePublicChannel,
+	}
+	if conv.Type == TypeIM {
+		out["user"] = conv.User
+		out["is_user_deleted"] = conv.IsUserDeleted
+	} else {
+		out["name"] = conv.Name
+	}
+	return out
+}
+
+// messageJSON renders a message, adding thread metadata when it has replies
+func messageJSON(msg Message, replies []Message) map[string]interface{} {
+	out := map[string]interface{}{
+		"type": msg.Type,
+		"user": msg.User,
+		"text": msg.Text,
+		"ts":   msg.Timestamp,
+	}
+	if msg.Subtype != "" {
+		out["subtype"] = msg.Subtype
+	}
+	if msg.ThreadTS != "" {
+		out["thread_ts"] = msg.ThreadTS
+	}
+	if len(replies) > 0 {
+		var replyUsers []string
+		seen := map[string]bool{}
+		for _, reply := range replies {
+			if !seen[reply.User] {
+				seen[reply.User] = true
+				replyUsers = append(replyUsers, reply.User)
+			}
+		}
+		out["thread_ts"] = msg.Timestamp
+		out["reply_count"] = len(replies)
+		out["reply_users"] = replyUsers
+		out["latest_reply"] = replies[len(replies)-1].Timestamp
+	}
+	return out
+}
+
+// writeJSON writes v as a JSON response with status 200, as Slack does even for API errors
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// sortMessages orders messages oldest first
+func sortMessages(messages []Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return compareTimestamps(messages[i].Timestamp, messages[j].Timestamp) < 0
+	})
+}
+
+// compareTimestamps compares two Slack timestamps ("seconds.micros") without
+// float rounding, returning -1, 0 or 1
+func compareTimestamps(a, b string) int {
+	aSec, aFrac := splitTimestamp(a)
+	bSec, bFrac := splitTimestamp(b)
+	switch {
+	case aSec < bSec:
+		return -1
+	case aSec > bSec:
+		return 1
+	case aFrac < bFrac:
+		return -1
+	case aFrac > bFrac:
+		return 1
+	}
+	return 0
+}
+
+// splitTimestamp splits a Slack timestamp into whole seconds and microseconds
+func splitTimestamp(ts string) (int64, int64) {
+	secPart, fracPart, _ := strings.Cut(ts, ".")
+	sec, _ := strconv.ParseInt(secPart, 10, 64)
+	fracPart = (fracPart + "000000")[:6]
+	frac, _ := strconv.ParseInt(fracPart, 10, 64)
+	return sec, frac
+}
@@ -0,0 +1,179 @@
+package slacktest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor/slack"
+)
+
+// newClient returns a real slack.Client pointed at the fake server
+func newClient(s *Server) *slack.Client {
+	client := slack.NewClient(DefaultXoxcToken, DefaultXoxdToken, slack.WithBaseURL(s.URL()))
+	return client
+}
+
+// TestAuthAndCookies tests that the fake accepts the real client's credentials and rejects bad ones
+func TestAuthAndCookies(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	userID, err := newClient(s).TestAuth(context.Background())
+	if err != nil {
+		t.Fatalf("TestAuth failed: %v", err)
+	}
+	if userID != DefaultUserID {
+		t.Errorf("Expected user %s, got %s", DefaultUserID, userID)
+	}
+
+	bad := slack.NewClient(DefaultXoxcToken, "xoxd-wrong", slack.WithBaseURL(s.URL()))
+	if _, err := bad.TestAuth(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Errorf("Expected invalid_auth for wrong d cookie, got %v", err)
+	}
+
+	s.SetAuthError("token_revoked")
+	if _, err := newClient(s).TestAuth(context.Background()); err == nil || !strings.Contains(err.Error(), "token_revoked") {
+		t.Errorf("Expected token_revoked, got %v", err)
+	}
+}
+
+// TestPaginationAndHistory tests that small pages are stitched together by the client
+func TestPaginationAndHistory(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.SetPageSize(2)
+
+	for _, id := range []string{"D1", "D2", "D3", "D4", "D5"} {
+		s.AddDM(id, "U"+id)
+	}
+	s.AddConversation(Conversation{ID: "C1", Name: "general", Type: TypePublicChannel})
+
+	client := newClient(s)
+	conversations, err := client.GetDMConversations(context.Background())
+	if err != nil {
+		t.Fatalf("GetDMConversations failed: %v", err)
+	}
+	if len(conversations) != 5 {
+		t.Errorf("Expected 5 DMs (channel excluded), got %d", len(conversations))
+	}
+	if got := s.RequestCount("conversations.list"); got != 3 {
+		t.Errorf("Expected 3 conversations.list pages, got %d", got)
+	}
+
+	first := s.PostMessage("D1", "UD1", "one")
+	s.PostMessage("D1", "UD1", "two")
+	s.PostMessage("D1", "UD1", "three")
+
+	messages, err := client.GetConversationHistory(context.Background(), "D1", first)
+	if err != nil {
+		t.Fatalf("GetConversationHistory failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Text != "three" || messages[1].Text != "two" {
+		t.Errorf("Expected [three two] after oldest, got %+v", messages)
+	}
+}
+
+// TestRateLimit tests that scripted 429s are retried by the client
+func TestRateLimit(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddUser(User{ID: "U1", Name: "alice", RealName: "Alice"})
+	s.RateLimit("users.info", 1, 0)
+
+	start := time.Now()
+	user, err := newClient(s).GetUserInfo(context.Background(), "U1")
+	if err != nil {
+		t.Fatalf("GetUserInfo failed: %v", err)
+	}
+	if user.RealName != "Alice" {
+		t.Errorf("Expected Alice, got %s", user.RealName)
+	}
+	if got := s.RequestCount("users.info"); got != 2 {
+		t.Errorf("Expected 2 requests (one 429), got %d", got)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Retry took unexpectedly long")
+	}
+}
+
+// TestTimestampsIncrease tests that generated timestamps are unique and ordered
+func TestTimestampsIncrease(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDM("D1", "U1")
+
+	prev := ""
+	for i := 0; i < 100; i++ {
+		ts := s.PostMessage("D1", "U1", "x")
+		if prev != "" && compareTimestamps(ts, prev) <= 0 {
+			t.Fatalf("Timestamp %s not after %s", ts, prev)
+		}
+		prev = ts
+	}
+}
+
+// TestClientCounts tests that client.counts reports each conversation's newest message
+func TestClientCounts(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDM("D1", "U1")
+	s.AddDM("D2", "U2")
+	s.AddConversation(Conversation{ID: "C1", Name: "general", Type: TypePublicChannel, IsMember: true})
+	s.AddConversation(Conversation{ID: "C2", Name: "random", Type: TypePublicChannel})
+	ts := s.PostMessage("D1", "U1", "hi")
+	s.PostMessage("C2", "U2", "not joined")
+
+	client := newClient(s)
+	latest, err := client.GetLatestTimestamps(context.Background())
+	if err != nil {
+		t.Fatalf("GetLatestTimestamps failed: %v", err)
+	}
+	if latest["D1"] != ts || latest["D2"] != "" || len(latest) != 3 {
+		t.Errorf("Unexpected latest timestamps %v", latest)
+	}
+	if _, ok := latest["C2"]; ok {
+		t.Error("Expected channels the user hasn't joined to be omitted")
+	}
+
+	s.FailMethod("client.counts", "unknown_method")
+	if _, err := client.GetLatestTimestamps(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown_method") {
+		t.Errorf("Expected unknown_method, got %v", err)
+	}
+}
+
+// TestRealtime tests that connected real-time clients receive message events
+func TestRealtime(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddDM("D1", "U1")
+
+	conn, err := newClient(s).ConnectRealtime(context.Background())
+	if err != nil {
+		t.Fatalf("ConnectRealtime failed: %v", err)
+	}
+	defer conn.Close()
+	if got := s.RealtimeClients(); got != 1 {
+		t.Errorf("Expected 1 real-time client, got %d", got)
+	}
+
+	ts := s.PostMessage("D1", "U1", "hello")
+	event, err := conn.NextEvent()
+	if err != nil {
+		t.Fatalf("NextEvent failed: %v", err)
+	}
+	if event.ChannelID != "D1" || event.User != "U1" || event.Timestamp != ts {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	s.DisconnectRealtime()
+	if _, err := conn.NextEvent(); err == nil {
+		t.Error("Expected an error after the server dropped the connection")
+	}
+
+	s.SetAuthError("invalid_auth")
+	if _, err := newClient(s).ConnectRealtime(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Errorf("Expected invalid_auth, got %v", err)
+	}
+}
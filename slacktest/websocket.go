@@ -0,0 +1,196 @@
+package slacktest
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// realtimePath is where rtm.connect points clients
+const realtimePath = "/websocket"
+
+// realtimeConn is a connected real-time client: a hijacked connection speaking
+// the server side of RFC 6455 (unmasked frames out, masked frames in)
+type realtimeConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// rtmConnect returns the websocket URL of this server
+func (s *Server) rtmConnect(w http.ResponseWriter) {
+	writeJSON(w, map[string]interface{}{
+		"ok":   true,
+		"url":  "ws" + strings.TrimPrefix(s.server.URL, "http") + realtimePath,
+		"self": map[string]interface{}{"id": s.userID, "name": s.users[s.userID].Name},
+		"team": map[string]interface{}{"id": DefaultTeamID, "name": DefaultTeam},
+	})
+}
+
+// serveRealtime upgrades an authenticated request to a websocket, greets it with
+// "hello" and answers pings until the client disconnects
+func (s *Server) serveRealtime(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[realtimePath]++
+	d, err := r.Cookie("d")
+	authorized := s.authError == "" && err == nil && d.Value == s.xoxdToken
+	s.mu.Unlock()
+
+	if !authorized {
+		http.Error(w, "invalid_auth", http.StatusUnauthorized)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+
+	c := &realtimeConn{conn: conn, r: rw.Reader}
+	s.mu.Lock()
+	s.realtime[c] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.realtime, c)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	c.send(map[string]interface{}{"type": "hello"})
+	for {
+		opcode, payload, err := c.readFrame()
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case 0x8: // Close
+			c.writeFrame(0x8, nil)
+			return
+		case 0x9: // Ping
+			c.writeFrame(0xA, payload)
+		case 0x1: // Text
+			var msg struct {
+				ID   int    `json:"id"`
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(payload, &msg) == nil && msg.Type == "ping" {
+				c.send(map[string]interface{}{"type": "pong", "reply_to": msg.ID})
+			}
+		}
+	}
+}
+
+// broadcast sends an event to every connected real-time client. Caller holds s.mu.
+func (s *Server) broadcast(event map[string]interface{}) {
+	for c := range s.realtime {
+		c.send(event)
+	}
+}
+
+// RealtimeClients returns how many real-time websocket clients are connected
+func (s *Server) RealtimeClients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.realtime)
+}
+
+// DisconnectRealtime drops every real-time connection, as a network failure would
+func (s *Server) DisconnectRealtime() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.realtime {
+		c.conn.Close()
+		delete(s.realtime, c)
+	}
+}
+
+// send writes an event as a text frame
+func (c *realtimeConn) send(event map[string]interface{}) {
+	data, _ := json.Marshal(event)
+	c.writeFrame(0x1, data)
+}
+
+// writeFrame writes a single unmasked frame
+func (c *realtimeConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// readFrame reads one client frame and unmasks it. Fragmented messages are not
+// supported; the monitor never sends them.
+func (c *realtimeConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	var mask [4]byte
+	if header[1]&0x80 != 0 {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return header[0] & 0x0f, payload, nil
+}
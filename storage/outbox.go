@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+const (
+	outboxBaseBackoff  = 5 * time.Second  // Delay before the first retry
+	outboxMaxBackoff   = 15 * time.Minute // Cap on the retry delay
+	deliveredRetention = 48 * time.Hour   // How long delivered IDs are remembered for dedupe
+)
+
+// outboxEntry is a notification awaiting delivery
+type outboxEntry struct {
+	Notification monitor.Notification
+	EnqueuedAt   time.Time
+	Attempts     int       // Failed delivery attempts so far
+	NextAttempt  time.Time // Zero means as soon as possible
+	LastError    string
+
+	inFlight bool // Handed to the notifier, awaiting a result (not persisted)
+}
+
+// outboxFile is the on-disk form of the outbox
+type outboxFile struct {
+	Pending   []*outboxEntry
+	Delivered map[string]time.Time // delivery ID -> when it was delivered
+}
+
+// asyncNotifier is a notifier that queues notifications and reports their
+// delivery later, such as notification.Dispatcher
+type asyncNotifier interface {
+	monitor.Notifier
+	OnDelivery(fn func(batch []monitor.Notification, err error))
+}
+
+// Outbox implements the monitor.Notifier interface with durable, at-least-once
+// delivery. SendNotification persists the notification before returning, so
+// callers may advance their own state once it succeeds; a background sender
+// (Run) delivers it to the wrapped notifier and retries failures with
+// exponential backoff. Notifications are deduplicated by delivery ID (the
+// conversation and Slack ts), so re-sending a message after a crash is harmless.
+type Outbox struct {
+	path     string
+	notifier monitor.Notifier
+	async    bool // notifier reports results through OnDelivery
+
+	baseBackoff time.Duration // Delay before the first retry
+	maxBackoff  time.Duration // Cap on the retry delay
+
+	mu        sync.Mutex
+	pending   []*outboxEntry
+	delivered map[string]time.Time
+	wake      chan struct{}
+	nextID    int64 // For notifications without a Slack message ID
+}
+
+// NewOutbox creates an outbox that delivers to notifier, restoring undelivered
+// notifications from outbox.json in dir
+func NewOutbox(dir string, notifier monitor.Notifier) (*Outbox, error) {
+	o := &Outbox{
+		path:        filepath.Join(dir, "outbox.json"),
+		notifier:    notifier,
+		delivered:   make(map[string]time.Time),
+		wake:        make(chan struct{}, 1),
+		baseBackoff: outboxBaseBackoff,
+		maxBackoff:  outboxMaxBackoff,
+	}
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+
+	if n, ok := notifier.(asyncNotifier); ok {
+		o.async = true
+		n.OnDelivery(o.recordDelivery)
+	}
+	return o, nil
+}
+
+// SendNotification durably enqueues a notification for delivery. Notifications
+// that are already pending or were recently delivered are ignored.
+func (o *Outbox) SendNotification(n monitor.Notification) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if n.ID == "" {
+		n.ID = n.DeliveryID()
+	}
+	if n.ID == "" {
+		o.nextID++
+		n.ID = fmt.Sprintf("local/%d.%d", time.Now().UnixNano(), o.nextID)
+	}
+	if _, ok := o.delivered[n.ID]; ok {
+		return nil
+	}
+	for _, entry := range o.pending {
+		if entry.Notification.ID == n.ID {
+			return nil
+		}
+	}
+
+	o.pending = append(o.pending, &outboxEntry{Notification: n, EnqueuedAt: time.Now()})
+	if err := o.saveLocked(); err != nil {
+		o.pending = o.pending[:len(o.pending)-1]
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	o.signal()
+	return nil
+}
+
+// Pending returns the number of notifications awaiting delivery
+func (o *Outbox) Pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// Run delivers pending notifications until ctx is cancelled
+func (o *Outbox) Run(ctx context.Context) {
+	if n := o.Pending(); n > 0 {
+		log.Printf("Outbox has %d undelivered notification(s), resending", n)
+	}
+
+	for {
+		wait := o.sendDue()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-o.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// sendDue hands every due notification to the notifier and returns how long to
+// wait before the next retry is due
+func (o *Outbox) sendDue() time.Duration {
+	o.mu.Lock()
+	now := time.Now()
+	var due []monitor.Notification
+	wait := o.maxBackoff
+	for _, entry := range o.pending {
+		if entry.inFlight {
+			continue
+		}
+		if until := entry.NextAttempt.Sub(now); until > 0 {
+			if until < wait {
+				wait = until
+			}
+			continue
+		}
+		entry.inFlight = true
+		due = append(due, entry.Notification)
+	}
+	o.mu.Unlock()
+
+	for _, n := range due {
+		err := o.notifier.SendNotification(n)
+		if !o.async || err != nil {
+			o.recordDelivery([]monitor.Notification{n}, err)
+		}
+	}
+	return wait
+}
+
+// recordDelivery records the outcome of delivering a batch: successes are
+// removed and remembered for dedupe, failures are rescheduled with backoff,
+// and notifications for targets that no longer exist are dropped
+func (o *Outbox) recordDelivery(batch []monitor.Notification, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	ids := make(map[string]bool, len(batch))
+	for _, n := range batch {
+		ids[n.ID] = true
+	}
+
+	remaining := o.pending[:0]
+	for _, entry := range o.pending {
+		if !ids[entry.Notification.ID] {
+			remaining = append(remaining, entry)
+			continue
+		}
+		entry.inFlight = false
+		if err == nil {
+			o.delivered[entry.Notification.ID] = now
+			continue
+		}
+
+		if errors.Is(err, monitor.ErrUnknownTarget) {
+			// Its targets were removed from the configuration since it was queued
+			log.Printf("Dropping undeliverable notification: %s: %v", entry.Notification, err)
+			continue
+		}
+
+		entry.Attempts++
+		entry.LastError = err.Error()
+		delay := o.backoff(entry.Attempts)
+		entry.NextAttempt = now.Add(delay)
+		log.Printf("Delivery failed (attempt %d), retrying in %s: %s: %v", entry.Attempts, delay, entry.Notification, err)
+		remaining = append(remaining, entry)
+	}
+	o.pending = remaining
+
+	if err := o.saveLocked(); err != nil {
+		log.Printf("Failed to save outbox: %v", err)
+	}
+	o.signal()
+}
+
+// signal wakes the sender without blocking
+func (o *Outbox) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// backoff returns the retry delay after the given number of failed attempts
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay := o.baseBackoff
+	for i := 1; i < attempts && delay < o.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > o.maxBackoff {
+		delay = o.maxBackoff
+	}
+	return delay
+}
+
+// load restores the outbox from disk
+func (o *Outbox) load() error {
+	data, err := os.ReadFile(o.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read outbox file: %w", err)
+	}
+
+	var file outboxFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse outbox file: %w", err)
+	}
+	o.pending = file.Pending
+	if file.Delivered != nil {
+		o.delivered = file.Delivered
+	}
+	return nil
+}
+
+// saveLocked writes the outbox to disk atomically; the caller must hold o.mu
+func (o *Outbox) saveLocked() error {
+	// Forget delivered IDs once a duplicate is no longer possible
+	for id, at := range o.delivered {
+		if time.Since(at) > deliveredRetention {
+			delete(o.delivered, id)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(o.path), 0700); err != nil {
+		return fmt.Errorf("failed to create monitor directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(outboxFile{Pending: o.pending, Delivered: o.delivered}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox: %w", err)
+	}
+
+	tempPath := o.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write temporary outbox file: %w", err)
+	}
+	if err := os.Rename(tempPath, o.path); err != nil {
+		return fmt.Errorf("failed to rename outbox file: %w", err)
+	}
+	return nil
+}
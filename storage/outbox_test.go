@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FourPalms/golang-slack-monitor"
+)
+
+// flakyNotifier fails a set number of times before delivering
+type flakyNotifier struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	delivered []monitor.Notification
+}
+
+func (f *flakyNotifier) SendNotification(n monitor.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("ntfy unavailable")
+	}
+	f.delivered = append(f.delivered, n)
+	return nil
+}
+
+func (f *flakyNotifier) deliveredCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.delivered)
+}
+
+// newTestOutbox creates an outbox stored in dir
+func newTestOutbox(t *testing.T, dir string, notifier monitor.Notifier) *Outbox {
+	t.Helper()
+	outbox, err := NewOutbox(dir, notifier)
+	if err != nil {
+		t.Fatalf("NewOutbox returned error: %v", err)
+	}
+	outbox.baseBackoff = 10 * time.Millisecond
+	outbox.maxBackoff = 50 * time.Millisecond
+	return outbox
+}
+
+// waitUntil polls until cond is true or fails the test
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestOutboxRetriesWithBackoff tests that failed deliveries are retried until they succeed
+func TestOutboxRetriesWithBackoff(t *testing.T) {
+	dir := t.TempDir()
+	notifier := &flakyNotifier{failures: 2}
+	outbox := newTestOutbox(t, dir, notifier)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go outbox.Run(ctx)
+
+	if err := outbox.SendNotification(monitor.Notification{ConversationID: "D1", Timestamp: "1.000001", Sender: "Alice", Text: "hi"}); err != nil {
+		t.Fatalf("SendNotification returned error: %v", err)
+	}
+
+	waitUntil(t, func() bool { return notifier.deliveredCount() == 1 })
+	waitUntil(t, func() bool { return outbox.Pending() == 0 })
+	if notifier.attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", notifier.attempts)
+	}
+}
+
+// TestOutboxDedupe tests that a message is enqueued only once, even after delivery
+func TestOutboxDedupe(t *testing.T) {
+	dir := t.TempDir()
+	notifier := &flakyNotifier{}
+	outbox := newTestOutbox(t, dir, notifier)
+	n := monitor.Notification{ConversationID: "D1", Timestamp: "1.000001", Sender: "Alice", Text: "hi"}
+
+	outbox.SendNotification(n)
+	outbox.SendNotification(n)
+	if outbox.Pending() != 1 {
+		t.Fatalf("Expected 1 pending notification, got %d", outbox.Pending())
+	}
+
+	outbox.sendDue()
+	outbox.SendNotification(n)
+	if outbox.Pending() != 0 || notifier.deliveredCount() != 1 {
+		t.Errorf("Expected delivered message not to be re-enqueued (pending %d, delivered %d)", outbox.Pending(), notifier.deliveredCount())
+	}
+}
+
+// unknownTargetNotifier rejects every notification as routed to a removed target
+type unknownTargetNotifier struct{}
+
+func (unknownTargetNotifier) SendNotification(n monitor.Notification) error {
+	return fmt.Errorf("%w %s", monitor.ErrUnknownTarget, strings.Join(n.Targets, ", "))
+}
+
+// TestOutboxDropsUnknownTargets tests that notifications whose targets no
+// longer exist are given up on rather than retried
+func TestOutboxDropsUnknownTargets(t *testing.T) {
+	outbox := newTestOutbox(t, t.TempDir(), unknownTargetNotifier{})
+	outbox.SendNotification(monitor.Notification{ConversationID: "D1", Timestamp: "1.000001", Text: "hi", Targets: []string{"pager"}})
+
+	outbox.sendDue()
+	if outbox.Pending() != 0 {
+		t.Errorf("Expected the notification to be dropped, got %d pending", outbox.Pending())
+	}
+}
+
+// TestOutboxSurvivesRestart tests that undelivered notifications are restored from disk
+func TestOutboxSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestOutbox(t, dir, &flakyNotifier{failures: 1})
+	first.SendNotification(monitor.Notification{ConversationID: "D1", Timestamp: "1.000001", Sender: "Alice", Text: "hi"})
+	first.sendDue() // Fails and is rescheduled
+
+	notifier := &flakyNotifier{}
+	second := newTestOutbox(t, dir, notifier)
+	if second.Pending() != 1 {
+		t.Fatalf("Expected 1 restored notification, got %d", second.Pending())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go second.Run(ctx)
+	waitUntil(t, func() bool { return notifier.deliveredCount() == 1 })
+	if got := notifier.delivered[0].String(); got != "DM from Alice: hi" {
+		t.Errorf("Unexpected restored notification %q", got)
+	}
+}
+
+// asyncStub reports deliveries through OnDelivery, like notification.Dispatcher
+type asyncStub struct {
+	mu         sync.Mutex
+	queued     []monitor.Notification
+	onDelivery func([]monitor.Notification, error)
+}
+
+func (a *asyncStub) SendNotification(n monitor.Notification) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.queued = append(a.queued, n)
+	return nil
+}
+
+func (a *asyncStub) OnDelivery(fn func([]monitor.Notification, error)) {
+	a.onDelivery = fn
+}
+
+// TestOutboxAsyncNotifier tests that accepted notifications stay pending until delivery is reported
+func TestOutboxAsyncNotifier(t *testing.T) {
+	dir := t.TempDir()
+	notifier := &asyncStub{}
+	outbox := newTestOutbox(t, dir, notifier)
+	outbox.SendNotification(monitor.Notification{ConversationID: "D1", Timestamp: "1.000001", Text: "hi"})
+
+	outbox.sendDue()
+	if outbox.Pending() != 1 {
+		t.Fatalf("Expected notification to stay pending until delivery is reported, got %d", outbox.Pending())
+	}
+	outbox.sendDue()
+	if len(notifier.queued) != 1 {
+		t.Errorf("Expected in-flight notification not to be resent, got %d sends", len(notifier.queued))
+	}
+
+	notifier.onDelivery(notifier.queued, nil)
+	if outbox.Pending() != 0 {
+		t.Errorf("Expected no pending notifications after delivery, got %d", outbox.Pending())
+	}
+}
@@ -15,17 +15,11 @@ type FileStore struct {
 	statePath string
 }
 
-// NewFileStore creates a new file-based state store
-func NewFileStore() *FileStore {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatalf("Failed to get home directory: %v", err)
-	}
-	monitorDir := filepath.Join(home, ".slack-monitor")
-	statePath := filepath.Join(monitorDir, "state.json")
-
+// NewFileStore creates a file-based state store keeping state.json in dir,
+// which is created on first save
+func NewFileStore(dir string) *FileStore {
 	return &FileStore{
-		statePath: statePath,
+		statePath: filepath.Join(dir, "state.json"),
 	}
 }
 
@@ -36,6 +30,7 @@ func (fs *FileStore) Load() (*monitor.State, error) {
 		log.Println("No existing state file found, creating new state")
 		return &monitor.State{
 			LastChecked: make(map[string]string),
+			Threads:     make(map[string]map[string]string),
 		}, nil
 	}
 
@@ -49,10 +44,13 @@ func (fs *FileStore) Load() (*monitor.State, error) {
 		return nil, fmt.Errorf("failed to parse state file: %w", err)
 	}
 
-	// Ensure map is initialized
+	// Ensure maps are initialized
 	if state.LastChecked == nil {
 		state.LastChecked = make(map[string]string)
 	}
+	if state.Threads == nil {
+		state.Threads = make(map[string]map[string]string)
+	}
 
 	log.Printf("State loaded successfully (%d conversations tracked)", len(state.LastChecked))
 	return &state, nil
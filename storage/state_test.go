@@ -1,7 +1,6 @@
 package storage
 
 import (
-	"os"
 	"testing"
 )
 
@@ -9,12 +8,9 @@ import (
 func TestLoadSaveState(t *testing.T) {
 	// Create temp directory
 	tmpDir := t.TempDir()
-	origHome := os.Getenv("HOME")
-	defer os.Setenv("HOME", origHome)
-	os.Setenv("HOME", tmpDir)
 
 	// Create file store
-	store := NewFileStore()
+	store := NewFileStore(tmpDir)
 
 	// Test 1: Load non-existent state (should create new)
 	state, err := store.Load()
@@ -54,11 +50,8 @@ func TestLoadSaveState(t *testing.T) {
 // State must be saved on first check of a conversation, not just when messages are found.
 func TestFirstCheckStatePersistence(t *testing.T) {
 	tmpDir := t.TempDir()
-	origHome := os.Getenv("HOME")
-	defer os.Setenv("HOME", origHome)
-	os.Setenv("HOME", tmpDir)
 
-	store := NewFileStore()
+	store := NewFileStore(tmpDir)
 
 	// Start with empty state (simulating first run)
 	state, err := store.Load()
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultThreadWatchDays = 7              // How long a thread stays watched after its last reply
+	unrepliedThreadWatch   = 24 * time.Hour // How long a thread with no replies yet stays watched
+)
+
+// watchThread starts watching msg's thread if replies to it are relevant to us:
+// every thread in a DM or group DM, and in channels threads we started, replied
+// to, or were mentioned in. isNew reports whether msg itself is newer than the
+// conversation's last check; if not, replies it already has were posted before
+// we started watching and are not notified.
+func (m *Monitor) watchThread(conv Conversation, msg Message, isNew bool, state *State) {
+	// Only top-level messages can be thread parents
+	if msg.ThreadTS != "" && msg.ThreadTS != msg.Timestamp {
+		return
+	}
+	if _, watched := state.Threads[conv.ID][msg.Timestamp]; watched {
+		return
+	}
+	if !isNew && msg.ReplyCount == 0 {
+		return
+	}
+	if conv.IsChannel() && !m.participatesIn(msg) {
+		return
+	}
+
+	lastReply := msg.Timestamp
+	if !isNew && msg.LatestReply != "" {
+		lastReply = msg.LatestReply
+	}
+
+	if state.Threads[conv.ID] == nil {
+		state.Threads[conv.ID] = make(map[string]string)
+	}
+	state.Threads[conv.ID][msg.Timestamp] = lastReply
+}
+
+// participatesIn reports whether we started, replied to, or were mentioned in a thread
+func (m *Monitor) participatesIn(msg Message) bool {
+	if msg.User == m.userID {
+		return true
+	}
+	for _, user := range msg.ReplyUsers {
+		if user == m.userID {
+			return true
+		}
+	}
+	return parseMentions(msg.Text).mentionOf(m.userID, m.userGroups) != ""
+}
+
+// threadsDue reports whether a conversation's watched threads need its history
+// fetched although no new message was posted. Replies don't move the
+// conversation's latest timestamp, so threads that already have replies are
+// checked every cycle, and threads still waiting for their first reply (every
+// recent DM message) only at the warm tier interval.
+func (m *Monitor) threadsDue(channelID string, state *State, now time.Time) bool {
+	unreplied := false
+	for threadTS, lastReply := range state.Threads[channelID] {
+		if lastReply != threadTS {
+			return true
+		}
+		unreplied = true
+	}
+	return unreplied && now.Sub(m.lastFetched[channelID]) >= m.config.TierInterval(TierWarm)
+}
+
+// threadWindowStart returns the history "oldest" bound needed to see every watched
+// thread parent in the conversation, or "" if no thread predates lastChecked
+func threadWindowStart(threads map[string]string, lastChecked string) string {
+	oldest := ""
+	for threadTS := range threads {
+		if oldest == "" || compareTimestamps(threadTS, oldest) < 0 {
+			oldest = threadTS
+		}
+	}
+	if oldest == "" || compareTimestamps(oldest, lastChecked) > 0 {
+		return ""
+	}
+	// "oldest" is exclusive, so step back one microsecond to include the parent
+	return previousTimestamp(oldest)
+}
+
+// checkThreads fetches new replies for watched threads whose latest_reply moved past
+// what we've seen, and notifies on replies from other users. parents holds the
+// thread parents returned by this cycle's history fetch, keyed by timestamp.
+// Threads idle past the watch window are dropped.
+func (m *Monitor) checkThreads(ctx context.Context, conv Conversation, parents map[string]Message, state *State) {
+	threads := state.Threads[conv.ID]
+	if len(threads) == 0 {
+		return
+	}
+
+	watchDays := m.config.Monitor.ThreadWatchDays
+	if watchDays <= 0 {
+		watchDays = defaultThreadWatchDays
+	}
+	now := time.Now()
+
+	for threadTS, lastReply := range threads {
+		// Drop threads that never got a reply, or have gone quiet
+		if lastReply == threadTS && now.Sub(parseTimestamp(threadTS)) > unrepliedThreadWatch {
+			delete(threads, threadTS)
+			continue
+		}
+		if lastReply != threadTS && parseTimestamp(lastReply).Before(now.AddDate(0, 0, -watchDays)) {
+			delete(threads, threadTS)
+			continue
+		}
+
+		// Skip the replies call when the parent shows nothing new. If the parent is
+		// missing (deleted, or beyond the history page cap) fetch replies anyway.
+		if parent, ok := parents[threadTS]; ok {
+			if parent.LatestReply == "" || compareTimestamps(parent.LatestReply, lastReply) <= 0 {
+				continue
+			}
+		}
+
+		replies, err := m.slackClient.GetThreadReplies(ctx, conv.ID, threadTS, lastReply)
+		if err != nil {
+			log.Printf("Failed to fetch replies for thread %s in %s: %v", threadTS, conv.ID, err)
+			continue
+		}
+
+		// Replies are returned oldest first
+		for _, reply := range replies {
+			if compareTimestamps(reply.Timestamp, threads[threadTS]) <= 0 {
+				continue
+			}
+
+			if reply.User != "" && reply.Type == "message" && reply.User != m.slackClient.GetAuthenticatedUserID() {
+				// Replies carry ThreadTS, so the notification is titled as a thread reply
+				n := m.newNotification(ctx, conv, reply)
+				if mention := parseMentions(reply.Text).mentionOf(m.userID, m.userGroups); mention != "" {
+					n.IsMention, n.Mention = true, mention
+				}
+
+				if err := m.deliver(n, true, state); err != nil {
+					// Stop before this reply so the next cycle retries it
+					log.Printf("Failed to send notification for thread %s in %s: %v", threadTS, conv.ID, err)
+					break
+				}
+			}
+			threads[threadTS] = reply.Timestamp
+		}
+	}
+
+	if len(threads) == 0 {
+		delete(state.Threads, conv.ID)
+	}
+}
+
+// parseTimestamp converts a Slack timestamp to a time.Time
+func parseTimestamp(ts string) time.Time {
+	sec, micros := splitTimestamp(ts)
+	return time.Unix(sec, micros*1000)
+}
+
+// previousTimestamp returns the Slack timestamp one microsecond before ts
+func previousTimestamp(ts string) string {
+	sec, micros := splitTimestamp(ts)
+	if micros == 0 {
+		sec, micros = sec-1, 999999
+	} else {
+		micros--
+	}
+	return fmt.Sprintf("%d.%06d", sec, micros)
+}
+
+// compareTimestamps compares two Slack timestamps without float rounding, returning -1, 0 or 1
+func compareTimestamps(a, b string) int {
+	aSec, aMicros := splitTimestamp(a)
+	bSec, bMicros := splitTimestamp(b)
+	switch {
+	case aSec < bSec:
+		return -1
+	case aSec > bSec:
+		return 1
+	case aMicros < bMicros:
+		return -1
+	case aMicros > bMicros:
+		return 1
+	}
+	return 0
+}
+
+// splitTimestamp splits a Slack timestamp ("seconds.micros") into seconds and microseconds
+func splitTimestamp(ts string) (int64, int64) {
+	secPart, fracPart, _ := strings.Cut(ts, ".")
+	sec, _ := strconv.ParseInt(secPart, 10, 64)
+	fracPart = (fracPart + "000000")[:6]
+	micros, _ := strconv.ParseInt(fracPart, 10, 64)
+	return sec, micros
+}
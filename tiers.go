@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"time"
+)
+
+// Polling tiers. Conversations are bucketed by how recently they saw activity,
+// and quieter tiers are polled less often.
+const (
+	TierHot  = "hot"  // Active recently: polled every cycle
+	TierWarm = "warm" // Quiet for a while: polled every few minutes
+	TierCold = "cold" // Idle for weeks or months: polled rarely
+)
+
+const (
+	defaultHotWithinHours   = 24
+	defaultWarmWithinDays   = 30
+	defaultWarmIntervalSecs = 300
+	defaultColdIntervalSecs = 1800
+)
+
+// PollTiers configures tiered polling. Zero values use the defaults.
+type PollTiers struct {
+	HotWithinHours   int `json:"hot_within_hours"`      // Activity this recent makes a conversation hot (default 24)
+	WarmWithinDays   int `json:"warm_within_days"`      // Activity this recent makes it warm, otherwise cold (default 30)
+	WarmIntervalSecs int `json:"warm_interval_seconds"` // How often warm conversations are polled (default 300)
+	ColdIntervalSecs int `json:"cold_interval_seconds"` // How often cold conversations are polled (default 1800)
+}
+
+// Tier returns the polling tier of a conversation last active at lastActivity
+func (c *Config) Tier(lastActivity, now time.Time) string {
+	tiers := c.Monitor.Tiers
+	hot := time.Duration(orDefault(tiers.HotWithinHours, defaultHotWithinHours)) * time.Hour
+	warm := time.Duration(orDefault(tiers.WarmWithinDays, defaultWarmWithinDays)) * 24 * time.Hour
+	idle := now.Sub(lastActivity)
+	switch {
+	case !c.Monitor.TieredPolling || idle < hot:
+		return TierHot
+	case idle < warm:
+		return TierWarm
+	}
+	return TierCold
+}
+
+// TierInterval returns how often conversations in a tier are polled. Hot
+// conversations are polled every cycle.
+func (c *Config) TierInterval(tier string) time.Duration {
+	switch tier {
+	case TierWarm:
+		return time.Duration(orDefault(c.Monitor.Tiers.WarmIntervalSecs, defaultWarmIntervalSecs)) * time.Second
+	case TierCold:
+		return time.Duration(orDefault(c.Monitor.Tiers.ColdIntervalSecs, defaultColdIntervalSecs)) * time.Second
+	}
+	return c.pollInterval()
+}
+
+// LastActivity returns the newest message timestamp recorded for a
+// conversation: its last checked message, or the latest reply in a watched thread
+func (s *State) LastActivity(channelID string) time.Time {
+	latest := s.LastChecked[channelID]
+	for _, lastReply := range s.Threads[channelID] {
+		if latest == "" || compareTimestamps(lastReply, latest) > 0 {
+			latest = lastReply
+		}
+	}
+	if latest == "" {
+		return time.Time{}
+	}
+	return parseTimestamp(latest)
+}
+
+// tier returns a conversation's polling tier. Conversations never checked are hot.
+func (m *Monitor) tier(channelID string, state *State, now time.Time) string {
+	if _, tracked := state.LastChecked[channelID]; !tracked {
+		return TierHot
+	}
+	return m.config.Tier(state.LastActivity(channelID), now)
+}
+
+// isDue reports whether a conversation's tier says it should be polled now.
+// Conversations never polled by this process are always due.
+func (m *Monitor) isDue(conv Conversation, state *State, now time.Time) (string, bool) {
+	tier := m.tier(conv.ID, state, now)
+	last, polled := m.lastPolled[conv.ID]
+	if !polled || tier == TierHot {
+		return tier, true
+	}
+	return tier, now.Sub(last) >= m.config.TierInterval(tier)
+}
+
+// orDefault returns v, or def if v is not positive
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultWorkers = 4 // Conversations checked at once unless monitor.workers is set
+
+// workers returns how many conversations are checked concurrently
+func (c *Config) workers() int {
+	return orDefault(c.Monitor.Workers, defaultWorkers)
+}
+
+// checkConversations checks conversations on a bounded pool of workers. The
+// workers share the Slack client and so its rate limiter. Each check works on
+// its own view of state, so conversations never share State between goroutines;
+// the views are merged back in conversation order once every check is done.
+// Each conversation is checked by one worker from start to finish, so its
+// notifications keep their order. It returns the conversations whose checks
+// finished; the rest were cut off by ctx and are left for the next cycle.
+func (m *Monitor) checkConversations(ctx context.Context, conversations []Conversation, state *State) []Conversation {
+	views := make([]*State, len(conversations))
+	finished := make([]bool, len(conversations))
+	for i, conv := range conversations {
+		views[i] = state.conversationView(conv.ID)
+	}
+
+	workers := m.config.workers()
+	if workers > len(conversations) {
+		workers = len(conversations)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// Errors leave the conversation's view untouched past the failure,
+				// so the next cycle retries; keep checking the others
+				err := m.checkConversation(ctx, conversations[i], views[i])
+				finished[i] = err == nil || ctx.Err() == nil
+			}
+		}()
+	}
+
+feed:
+	for i := range conversations {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var checked []Conversation
+	for i, conv := range conversations {
+		state.merge(conv.ID, views[i])
+		if finished[i] {
+			checked = append(checked, conv)
+		}
+	}
+	return checked
+}
+
+// conversationView returns a State holding a copy of one conversation's
+// entries, for a check running alongside checks of other conversations.
+// Changes are copied back with merge.
+func (s *State) conversationView(channelID string) *State {
+	view := &State{
+		LastChecked: make(map[string]string),
+		Threads:     make(map[string]map[string]string),
+	}
+	if lastChecked, ok := s.LastChecked[channelID]; ok {
+		view.LastChecked[channelID] = lastChecked
+	}
+	if threads, ok := s.Threads[channelID]; ok {
+		copied := make(map[string]string, len(threads))
+		for threadTS, lastReply := range threads {
+			copied[threadTS] = lastReply
+		}
+		view.Threads[channelID] = copied
+	}
+	return view
+}
+
+// merge copies a conversation view's entries back: its last checked message and
+// watched threads replace the conversation's, and notifications it buffered for
+// the digest or held for quiet hours are appended
+func (s *State) merge(channelID string, view *State) {
+	if lastChecked, ok := view.LastChecked[channelID]; ok {
+		if s.LastChecked == nil {
+			s.LastChecked = make(map[string]string)
+		}
+		s.LastChecked[channelID] = lastChecked
+	}
+	if threads, ok := view.Threads[channelID]; ok {
+		if s.Threads == nil {
+			s.Threads = make(map[string]map[string]string)
+		}
+		s.Threads[channelID] = threads
+	} else {
+		delete(s.Threads, channelID)
+	}
+	for id, buffered := range view.Digest {
+		if s.Digest == nil {
+			s.Digest = make(map[string][]Notification)
+		}
+		s.Digest[id] = append(s.Digest[id], buffered...)
+	}
+	s.Held = append(s.Held, view.Held...)
+}